@@ -0,0 +1,177 @@
+package convoy_cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/frain-dev/convoy-cli/net"
+	"github.com/frain-dev/convoy-cli/util"
+)
+
+// Client calls the cli endpoints of a Convoy host with an api key.
+type Client struct {
+	host       string
+	apiKey     string
+	dispatcher *net.Dispatcher
+}
+
+func NewClient(host, apiKey string) (*Client, error) {
+	if util.IsStringEmpty(host) {
+		return nil, errors.New("host is required")
+	}
+
+	d, err := net.NewDispatcher(time.Second*10, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{host: strings.TrimSuffix(host, "/"), apiKey: apiKey, dispatcher: d}, nil
+}
+
+// Login registers the device with the host and returns the projects the
+// api key has access to, with the headers of the response.
+func (c *Client) Login(r *LoginRequest) (*LoginResponse, http.Header, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := c.send(http.MethodPost, "/stream/login", body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = checkResponse(resp); err != nil {
+		return nil, resp.ResponseHeader, err
+	}
+
+	var response LoginResponse
+	err = json.Unmarshal(resp.Body, &response)
+	if err != nil {
+		return nil, resp.ResponseHeader, err
+	}
+
+	return &response, resp.ResponseHeader, nil
+}
+
+// DeleteDevice deregisters a device, the host stops sending it events. A
+// device the host doesn't know is already deregistered.
+func (c *Client) DeleteDevice(deviceID string) error {
+	resp, err := c.send(http.MethodDelete, "/stream/devices/"+url.PathEscape(deviceID), nil)
+	if err != nil {
+		return err
+	}
+
+	if err = checkSupported(resp, "deleting devices"); err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return checkResponse(resp)
+}
+
+// RevokeApiKey revokes the api key of the client, it can't be used again.
+func (c *Client) RevokeApiKey() error {
+	resp, err := c.send(http.MethodPost, "/stream/revoke", nil)
+	if err != nil {
+		return err
+	}
+
+	if err = checkSupported(resp, "revoking api keys"); err != nil {
+		return err
+	}
+	return checkResponse(resp)
+}
+
+func (c *Client) send(method, path string, body []byte) (*net.Response, error) {
+	return c.dispatcher.SendCliRequest(c.host+path, method, c.apiKey, body)
+}
+
+// APIError is a response of the host with an error status.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+func checkResponse(resp *net.Response) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	message := strings.TrimSpace(string(resp.Body))
+	if util.IsStringEmpty(message) {
+		message = resp.Status
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: message}
+}
+
+// CLIAPIVersion is the first Convoy version serving the cli endpoints past
+// /stream/login and /stream/listen, e.g. devices, projects and web login.
+const CLIAPIVersion = "v0.9.0"
+
+// ErrUnsupported is returned when the host is too old for an endpoint.
+var ErrUnsupported = errors.New("unsupported by the host")
+
+// checkSupported returns ErrUnsupported when the host doesn't serve the
+// endpoint. Convoy answers the errors of its handlers as json, a plain 404
+// comes from its router not knowing the path.
+func checkSupported(resp *net.Response, what string) error {
+	if resp.StatusCode != http.StatusNotFound || strings.HasPrefix(resp.ResponseHeader.Get("Content-Type"), "application/json") {
+		return nil
+	}
+	return fmt.Errorf("%s requires Convoy %s or newer: %w", what, CLIAPIVersion, ErrUnsupported)
+}
+
+// Deregister deregisters the devices of the profile in use from its host,
+// and revokes its api key when revoke is set. It tries every device and
+// returns the ones that failed in one error.
+func (c *Config) Deregister(revoke bool) error {
+	if util.IsStringEmpty(c.Host) || util.IsStringEmpty(c.ActiveApiKey) {
+		return nil
+	}
+
+	client, err := NewClient(c.Host, c.ActiveApiKey)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	deleted := map[string]bool{}
+	for _, p := range c.Projects {
+		if util.IsStringEmpty(p.DeviceID) || deleted[p.DeviceID] {
+			continue
+		}
+		deleted[p.DeviceID] = true
+
+		err = client.DeleteDevice(p.DeviceID)
+		if errors.Is(err, ErrUnsupported) {
+			// every other request would fail the same way
+			return fmt.Errorf("failed to deregister from %s: %w", c.Host, err)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("device %s of project %s: %v", p.DeviceID, p.Name, err))
+		}
+	}
+
+	if revoke {
+		if err = client.RevokeApiKey(); err != nil {
+			failed = append(failed, fmt.Sprintf("api key: %v", err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to deregister from %s:\n  %s", c.Host, strings.Join(failed, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package convoy_cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Deregister(t *testing.T) {
+	tests := []struct {
+		name     string
+		revoke   bool
+		old      bool
+		statuses map[string]int
+		want     []string
+		wantErr  []string
+	}{
+		{
+			name: "devices",
+			want: []string{"DELETE /stream/devices/d1", "DELETE /stream/devices/d2"},
+		},
+		{
+			name:   "revoke",
+			revoke: true,
+			want:   []string{"DELETE /stream/devices/d1", "DELETE /stream/devices/d2", "POST /stream/revoke"},
+		},
+		{
+			name:     "unknown device",
+			statuses: map[string]int{"/stream/devices/d1": http.StatusNotFound},
+			want:     []string{"DELETE /stream/devices/d1", "DELETE /stream/devices/d2"},
+		},
+		{
+			name:    "host without devices",
+			revoke:  true,
+			old:     true,
+			want:    []string{"DELETE /stream/devices/d1"},
+			wantErr: []string{"deleting devices requires Convoy"},
+		},
+		{
+			name:     "failures are collected",
+			revoke:   true,
+			statuses: map[string]int{"/stream/devices/d1": http.StatusInternalServerError, "/stream/revoke": http.StatusForbidden},
+			want:     []string{"DELETE /stream/devices/d1", "DELETE /stream/devices/d2", "POST /stream/revoke"},
+			wantErr:  []string{"device d1 of project payments", "api key"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var requests []string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+
+				mu.Lock()
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				mu.Unlock()
+
+				if tt.old {
+					http.NotFound(w, r)
+					return
+				}
+
+				if status, ok := tt.statuses[r.URL.Path]; ok {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(status)
+				}
+			}))
+			defer server.Close()
+
+			c := &Config{Profile: &Profile{
+				Host:         server.URL,
+				ActiveApiKey: "key",
+				Projects: []ConfigProject{
+					{UID: "p1", Name: "payments", DeviceID: "d1"},
+					{UID: "p2", Name: "ci", DeviceID: "d2"},
+					{UID: "p3", Name: "billing", DeviceID: "d2"},
+					{UID: "p4", Name: "outgoing"},
+				},
+			}}
+
+			err := c.Deregister(tt.revoke)
+			if len(tt.wantErr) > 0 {
+				for _, want := range tt.wantErr {
+					require.ErrorContains(t, err, want)
+				}
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, requests)
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package convoy_cli
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	stdnet "net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/frain-dev/convoy-cli/net"
+	"github.com/frain-dev/convoy-cli/util"
+)
+
+// WebLoginTimeout is how long the callback flow waits for the browser.
+const WebLoginTimeout = 5 * time.Minute
+
+// Errors returned by the device code flow, named as in RFC 8628.
+var (
+	ErrAccessDenied = errors.New("the login was denied in the dashboard")
+	ErrExpiredToken = errors.New("the login code expired before it was authorized, run login again")
+)
+
+// WebLogin logs into a host by authorizing the cli in the dashboard, which
+// returns a token scoped to this device instead of a pasted api key.
+type WebLogin struct {
+	Host string
+
+	// HostName identifies this device, the host shows it on the
+	// authorization page and scopes the token to it
+	HostName string
+
+	// OpenURL opens the authorization page, the url is only printed when it fails
+	OpenURL func(url string) error
+	Out     io.Writer
+
+	// pollInterval overrides the interval of the device code flow in tests
+	pollInterval time.Duration
+	dispatcher   *net.Dispatcher
+}
+
+// WebLoginToken is the token a web login receives.
+type WebLoginToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+type tokenRequest struct {
+	Code         string `json:"code,omitempty"`
+	CodeVerifier string `json:"code_verifier,omitempty"`
+	DeviceCode   string `json:"device_code,omitempty"`
+	HostName     string `json:"host_name"`
+}
+
+type tokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// DeviceCodeResponse starts the device code flow, the user enters UserCode
+// at VerificationURI on any device while the cli polls for the token.
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+func NewWebLogin(host, hostName string, out io.Writer) (*WebLogin, error) {
+	d, err := net.NewDispatcher(time.Second*10, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebLogin{Host: host, HostName: hostName, Out: out, dispatcher: d}, nil
+}
+
+// Callback opens the authorization page in the browser, which redirects to
+// a server on localhost with a code once the user approves. The code is
+// exchanged for the token with the PKCE verifier only this process knows.
+func (w *WebLogin) Callback(ctx context.Context) (*WebLoginToken, error) {
+	state, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	// the authorization page of a host without web login would never
+	// redirect back, so the token endpoint is asked without a code first
+	resp, err := w.dispatcher.SendCliRequest(w.Host+"/stream/token", http.MethodPost, "", []byte("{}"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkSupported(resp, "web login"); err != nil {
+		return nil, err
+	}
+
+	l, err := stdnet.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the login callback server: %v", err)
+	}
+
+	redirectURI := fmt.Sprintf("http://%s/callback", l.Addr())
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("state") != state {
+			http.Error(rw, "invalid login state, run convoy-cli login again", http.StatusBadRequest)
+			return
+		}
+
+		if e := q.Get("error"); !util.IsStringEmpty(e) {
+			fmt.Fprintln(rw, "The login was denied, you can close this window.")
+			select {
+			case errs <- ErrAccessDenied:
+			default:
+			}
+			return
+		}
+
+		code := q.Get("code")
+		if util.IsStringEmpty(code) {
+			http.Error(rw, "missing login code", http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprintln(rw, "The cli is logged in, you can close this window.")
+		select {
+		case codes <- code:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = server.Serve(l)
+	}()
+	defer server.Close()
+
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{}
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	q.Set("host_name", w.HostName)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	w.open(fmt.Sprintf("%s/cli/authorize?%s", w.Host, q.Encode()))
+
+	ctx, cancel := context.WithTimeout(ctx, WebLoginTimeout)
+	defer cancel()
+
+	select {
+	case code := <-codes:
+		return w.requestToken("/stream/token", &tokenRequest{Code: code, CodeVerifier: verifier, HostName: w.HostName})
+	case err = <-errs:
+		return nil, err
+	case <-ctx.Done():
+		return nil, errors.New("timed out waiting for the login in the browser, run login with --device-code on machines without a browser")
+	}
+}
+
+// DeviceCode shows a code to enter in the dashboard on any device and polls
+// the host until it is authorized, for machines without a browser.
+func (w *WebLogin) DeviceCode(ctx context.Context) (*WebLoginToken, error) {
+	body, err := json.Marshal(&tokenRequest{HostName: w.HostName})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := w.dispatcher.SendCliRequest(w.Host+"/stream/device/code", http.MethodPost, "", body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkSupported(resp, "web login"); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to start the device login: %s", resp.Body)
+	}
+
+	var code DeviceCodeResponse
+	err = json.Unmarshal(resp.Body, &code)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(w.Out, "Enter the code %s at %s\n", code.UserCode, code.VerificationURI)
+	if !util.IsStringEmpty(code.VerificationURIComplete) {
+		w.open(code.VerificationURIComplete)
+	}
+
+	interval := w.pollInterval
+	if interval == 0 {
+		interval = time.Duration(code.Interval) * time.Second
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	expiresIn := time.Duration(code.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = WebLoginTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, expiresIn)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ErrExpiredToken
+		case <-time.After(interval):
+		}
+
+		token, err := w.requestToken("/stream/device/token", &tokenRequest{DeviceCode: code.DeviceCode, HostName: w.HostName})
+
+		var e *tokenError
+		if !errors.As(err, &e) {
+			return token, err
+		}
+
+		switch e.Code {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return nil, ErrAccessDenied
+		case "expired_token":
+			return nil, ErrExpiredToken
+		default:
+			return nil, err
+		}
+	}
+}
+
+// requestToken exchanges a code for the token, a refused exchange returns
+// a *tokenError.
+func (w *WebLogin) requestToken(path string, r *tokenRequest) (*WebLoginToken, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := w.dispatcher.SendCliRequest(w.Host+path, http.MethodPost, "", body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkSupported(resp, "web login"); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		e := &tokenError{}
+		if json.Unmarshal(resp.Body, e) == nil && !util.IsStringEmpty(e.Code) {
+			return nil, e
+		}
+		return nil, fmt.Errorf("failed to get the login token: %s", resp.Body)
+	}
+
+	var token WebLoginToken
+	err = json.Unmarshal(resp.Body, &token)
+	if err != nil {
+		return nil, err
+	}
+
+	if util.IsStringEmpty(token.Token) {
+		return nil, errors.New("the host returned an empty login token")
+	}
+
+	return &token, nil
+}
+
+func (w *WebLogin) open(url string) {
+	if w.OpenURL == nil || w.OpenURL(url) != nil {
+		fmt.Fprintf(w.Out, "Open this url to log in:\n\n    %s\n\n", url)
+		return
+	}
+	fmt.Fprintf(w.Out, "Opened %s in your browser\n", url)
+}
+
+func (e *tokenError) Error() string {
+	if util.IsStringEmpty(e.Description) {
+		return e.Code
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
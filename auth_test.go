@@ -0,0 +1,200 @@
+package convoy_cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthServer is the authorization side of a Convoy host.
+type fakeAuthServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	challenge string
+	polls     int
+
+	// deviceResponses are returned by the device token endpoint in order,
+	// the last one repeats
+	deviceResponses []string
+}
+
+func newFakeAuthServer(t *testing.T) *fakeAuthServer {
+	f := &fakeAuthServer{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream/token", func(w http.ResponseWriter, r *http.Request) {
+		var req tokenRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(req.CodeVerifier))
+		if req.Code != "code" || base64.RawURLEncoding.EncodeToString(sum[:]) != f.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_grant"}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"token": "web-token"}`)
+	})
+	mux.HandleFunc("/stream/device/code", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"device_code": "device", "user_code": "ABCD-EFGH", "verification_uri": "%s/cli/device", "expires_in": 60, "interval": 1}`, f.URL)
+	})
+	mux.HandleFunc("/stream/device/token", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		i := f.polls
+		if i >= len(f.deviceResponses) {
+			i = len(f.deviceResponses) - 1
+		}
+		f.polls++
+
+		if f.deviceResponses[i] == "token" {
+			fmt.Fprint(w, `{"token": "device-token"}`)
+			return
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"error": %q}`, f.deviceResponses[i])
+	})
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// browser approves, or denies, the login the way the dashboard redirects
+func (f *fakeAuthServer) browser(t *testing.T, query url.Values) func(string) error {
+	return func(authorizeURL string) error {
+		u, err := url.Parse(authorizeURL)
+		require.NoError(t, err)
+		require.Equal(t, "/cli/authorize", u.Path)
+		require.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+
+		f.mu.Lock()
+		f.challenge = u.Query().Get("code_challenge")
+		f.mu.Unlock()
+
+		if query.Get("state") == "" {
+			query.Set("state", u.Query().Get("state"))
+		}
+
+		go func() {
+			resp, err := http.Get(u.Query().Get("redirect_uri") + "?" + query.Encode())
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+}
+
+func TestWebLogin_Callback(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   url.Values
+		want    string
+		wantErr error
+	}{
+		{name: "approved", query: url.Values{"code": {"code"}}, want: "web-token"},
+		{name: "denied", query: url.Values{"error": {"access_denied"}}, wantErr: ErrAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeAuthServer(t)
+
+			w, err := NewWebLogin(f.URL, "laptop", io.Discard)
+			require.NoError(t, err)
+			w.OpenURL = f.browser(t, tt.query)
+
+			token, err := w.Callback(context.Background())
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, token.Token)
+		})
+	}
+}
+
+func TestWebLogin_CallbackIgnoresOtherStates(t *testing.T) {
+	f := newFakeAuthServer(t)
+
+	w, err := NewWebLogin(f.URL, "laptop", io.Discard)
+	require.NoError(t, err)
+	w.OpenURL = f.browser(t, url.Values{"code": {"code"}, "state": {"forged"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err = w.Callback(ctx)
+	require.Error(t, err)
+}
+
+func TestWebLogin_DeviceCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		want      string
+		wantErr   error
+	}{
+		{name: "approved", responses: []string{"authorization_pending", "authorization_pending", "token"}, want: "device-token"},
+		{name: "denied", responses: []string{"authorization_pending", "access_denied"}, wantErr: ErrAccessDenied},
+		{name: "expired", responses: []string{"expired_token"}, wantErr: ErrExpiredToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeAuthServer(t)
+			f.deviceResponses = tt.responses
+
+			w, err := NewWebLogin(f.URL, "laptop", io.Discard)
+			require.NoError(t, err)
+			w.pollInterval = time.Millisecond
+
+			token, err := w.DeviceCode(context.Background())
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, token.Token)
+			require.Equal(t, len(tt.responses), f.polls)
+		})
+	}
+}
+
+func TestWebLogin_Unsupported(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	w, err := NewWebLogin(server.URL, "laptop", io.Discard)
+	require.NoError(t, err)
+	w.OpenURL = func(string) error {
+		t.Fatal("opened the authorization page of a host without web login")
+		return nil
+	}
+
+	_, err = w.Callback(context.Background())
+	require.ErrorIs(t, err, ErrUnsupported)
+	require.ErrorContains(t, err, "web login requires Convoy "+CLIAPIVersion)
+
+	_, err = w.DeviceCode(context.Background())
+	require.ErrorIs(t, err, ErrUnsupported)
+}
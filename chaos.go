@@ -0,0 +1,305 @@
+package convoy_cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frain-dev/convoy-cli/net"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Time an event held back for reordering waits for a successor before it is forwarded anyway.
+const reorderWindow = 5 * time.Second
+
+var ErrChaosTimeout = errors.New("chaos: injected timeout")
+
+// Chaos injects faults on the path between the listener and the local target.
+// The faults of each delivery of an event are drawn from a source seeded with
+// the seed, the event and how often it was delivered, so a session can be
+// replayed by passing the same seed whatever the timing of its goroutines.
+type Chaos struct {
+	Seed          int64
+	Latency       *LatencyDistribution
+	TimeoutRate   float64
+	DuplicateRate float64
+	ReorderRate   float64
+	CorruptRate   float64
+
+	// TimeoutDelay is how long a timed out event waits before it fails, the
+	// timeout of the dispatcher when zero
+	TimeoutDelay time.Duration
+
+	mu         sync.Mutex
+	held       *CLIEvent
+	deliveries map[string]int
+}
+
+func NewChaos(seed int64) *Chaos {
+	return &Chaos{Seed: seed, deliveries: map[string]int{}}
+}
+
+// ForStream returns a Chaos with the same faults for one stream. Streams
+// hold back and count the deliveries of their own events, so the event held
+// back on a stream is released on that stream.
+func (c *Chaos) ForStream() *Chaos {
+	return &Chaos{
+		Seed:          c.Seed,
+		Latency:       c.Latency,
+		TimeoutRate:   c.TimeoutRate,
+		DuplicateRate: c.DuplicateRate,
+		ReorderRate:   c.ReorderRate,
+		CorruptRate:   c.CorruptRate,
+		TimeoutDelay:  c.TimeoutDelay,
+		deliveries:    map[string]int{},
+	}
+}
+
+func (c *Chaos) Validate() error {
+	rates := map[string]float64{
+		"timeout":   c.TimeoutRate,
+		"duplicate": c.DuplicateRate,
+		"reorder":   c.ReorderRate,
+		"corrupt":   c.CorruptRate,
+	}
+
+	for name, rate := range rates {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("chaos %s rate must be between 0 and 1", name)
+		}
+	}
+
+	return nil
+}
+
+// Reorder decides whether the event should be held back and returns the events
+// to deliver now, in order. A held event is released after the next event, or
+// passed to flush when no other event arrives within the reorder window.
+func (c *Chaos) Reorder(event *CLIEvent, flush func(*CLIEvent)) []*CLIEvent {
+	// drawn first, so the event's later faults don't depend on whether
+	// another event was held back when it arrived
+	reorder := hit(c.draws(event), c.ReorderRate)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.held != nil {
+		held := c.held
+		c.held = nil
+		tag(held, "reorder").Warnf("delivering event %s after event %s", held.UID, event.UID)
+		return []*CLIEvent{event, held}
+	}
+
+	if !reorder {
+		return []*CLIEvent{event}
+	}
+
+	tag(event, "reorder").Warnf("holding back event %s", event.UID)
+	c.held = event
+
+	time.AfterFunc(reorderWindow, func() {
+		c.mu.Lock()
+		if c.held != event {
+			c.mu.Unlock()
+			return
+		}
+		c.held = nil
+		c.mu.Unlock()
+
+		flush(event)
+	})
+
+	return nil
+}
+
+// Release returns the event currently held back for reordering, if any,
+// so it can be delivered before the session ends.
+func (c *Chaos) Release() *CLIEvent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	held := c.held
+	c.held = nil
+	return held
+}
+
+// Forward sends the event to url through d with the configured faults applied.
+func (c *Chaos) Forward(ctx context.Context, d *net.Dispatcher, url string, event *CLIEvent) (*net.Response, error) {
+	r := c.draws(event)
+
+	if c.Latency != nil {
+		delay := c.Latency.Sample(r)
+		tag(event, "latency").Warnf("delaying event %s by %v", event.UID, delay)
+		time.Sleep(delay)
+	}
+
+	if hit(r, c.TimeoutRate) {
+		delay := c.TimeoutDelay
+		if delay <= 0 {
+			delay = d.Timeout()
+		}
+
+		tag(event, "timeout").Warnf("dropping event %s with a timeout after %v", event.UID, delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+		return nil, ErrChaosTimeout
+	}
+
+	data := event.Data
+	if hit(r, c.CorruptRate) {
+		data = corrupt(r, data)
+		tag(event, "corrupt").Warnf("corrupted payload of event %s", event.UID)
+	}
+
+	res, err := d.ForwardCliEventWithContext(ctx, url, http.MethodPost, data, event.Headers)
+	if err != nil {
+		return res, err
+	}
+
+	if hit(r, c.DuplicateRate) {
+		tag(event, "duplicate").Warnf("delivering event %s a second time", event.UID)
+		return d.ForwardCliEventWithContext(ctx, url, http.MethodPost, data, event.Headers)
+	}
+
+	return res, nil
+}
+
+// draws returns the source the faults of this delivery of the event are drawn
+// from. The event is handled by one goroutine at a time, so the source needs
+// no lock.
+func (c *Chaos) draws(event *CLIEvent) *rand.Rand {
+	if event.chaos != nil {
+		return event.chaos
+	}
+
+	c.mu.Lock()
+	c.deliveries[event.UID]++
+	delivery := c.deliveries[event.UID]
+	c.mu.Unlock()
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%s/%d", c.Seed, event.UID, delivery)
+	event.chaos = rand.New(rand.NewSource(int64(h.Sum64())))
+	return event.chaos
+}
+
+// corrupt either truncates the payload or flips one of its bytes.
+func corrupt(r *rand.Rand, data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+
+	buf := make([]byte, len(data))
+	copy(buf, data)
+
+	if r.Intn(2) == 0 {
+		return buf[:r.Intn(len(buf))]
+	}
+
+	i := r.Intn(len(buf))
+	buf[i] ^= byte(1 + r.Intn(255))
+	return buf
+}
+
+func hit(r *rand.Rand, rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	return r.Float64() < rate
+}
+
+// tag records the fault on the event's span and returns a log entry tagged with it.
+func tag(event *CLIEvent, fault string) *log.Entry {
+	trace.SpanFromContext(event.context()).AddEvent("chaos", trace.WithAttributes(attribute.String("convoy.chaos.fault", fault)))
+	return log.WithFields(log.Fields{"chaos": fault, "event_id": event.UID})
+}
+
+// LatencyDistribution describes the delay added before forwarding an event.
+type LatencyDistribution struct {
+	Kind string
+	A    time.Duration
+	B    time.Duration
+}
+
+// ParseLatencyDistribution parses one of:
+//
+//	500ms               a fixed delay
+//	uniform:100ms-2s    a delay picked uniformly between the bounds
+//	normal:500ms,100ms  a normally distributed delay with the given mean and standard deviation
+//	exp:300ms           an exponentially distributed delay with the given mean
+func ParseLatencyDistribution(s string) (*LatencyDistribution, error) {
+	kind, spec, found := strings.Cut(s, ":")
+	if !found {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		return &LatencyDistribution{Kind: "fixed", A: d}, nil
+	}
+
+	var sep string
+	switch kind {
+	case "uniform":
+		sep = "-"
+	case "normal":
+		sep = ","
+	case "exp":
+		d, err := time.ParseDuration(spec)
+		if err != nil {
+			return nil, err
+		}
+		return &LatencyDistribution{Kind: kind, A: d}, nil
+	default:
+		return nil, fmt.Errorf("unknown latency distribution %q", kind)
+	}
+
+	first, second, found := strings.Cut(spec, sep)
+	if !found {
+		return nil, fmt.Errorf("%s latency requires two durations separated by %q", kind, sep)
+	}
+
+	a, err := time.ParseDuration(first)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := time.ParseDuration(second)
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == "uniform" && b < a {
+		return nil, errors.New("uniform latency upper bound is lower than the lower bound")
+	}
+
+	return &LatencyDistribution{Kind: kind, A: a, B: b}, nil
+}
+
+func (l *LatencyDistribution) Sample(r *rand.Rand) time.Duration {
+	var d float64
+
+	switch l.Kind {
+	case "uniform":
+		d = float64(l.A) + r.Float64()*float64(l.B-l.A)
+	case "normal":
+		d = float64(l.A) + r.NormFloat64()*float64(l.B)
+	case "exp":
+		d = r.ExpFloat64() * float64(l.A)
+	default:
+		d = float64(l.A)
+	}
+
+	return time.Duration(math.Max(d, 0))
+}
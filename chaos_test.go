@@ -0,0 +1,129 @@
+package convoy_cli
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/frain-dev/convoy-cli/net"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLatencyDistribution(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    *LatencyDistribution
+		wantErr bool
+	}{
+		{s: "500ms", want: &LatencyDistribution{Kind: "fixed", A: 500 * time.Millisecond}},
+		{s: "uniform:100ms-2s", want: &LatencyDistribution{Kind: "uniform", A: 100 * time.Millisecond, B: 2 * time.Second}},
+		{s: "normal:500ms,100ms", want: &LatencyDistribution{Kind: "normal", A: 500 * time.Millisecond, B: 100 * time.Millisecond}},
+		{s: "exp:300ms", want: &LatencyDistribution{Kind: "exp", A: 300 * time.Millisecond}},
+		{s: "uniform:2s-1s", wantErr: true},
+		{s: "normal:500ms", wantErr: true},
+		{s: "pareto:1s", wantErr: true},
+		{s: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseLatencyDistribution(tt.s)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLatencyDistribution_Sample(t *testing.T) {
+	l := &LatencyDistribution{Kind: "uniform", A: 100 * time.Millisecond, B: 200 * time.Millisecond}
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		d := l.Sample(r)
+		require.GreaterOrEqual(t, d, l.A)
+		require.LessOrEqual(t, d, l.B)
+	}
+}
+
+func TestChaos_SameSeedSameFaults(t *testing.T) {
+	uids := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		uids = append(uids, fmt.Sprintf("event-%d", i))
+	}
+
+	faults := func(seed int64, uids []string) map[string]bool {
+		c := NewChaos(seed)
+		out := map[string]bool{}
+		for _, uid := range uids {
+			out[uid] = hit(c.draws(&CLIEvent{UID: uid}), 0.5)
+		}
+		return out
+	}
+
+	reversed := make([]string, 0, len(uids))
+	for i := len(uids) - 1; i >= 0; i-- {
+		reversed = append(reversed, uids[i])
+	}
+
+	// the faults of an event don't depend on the order events are handled in
+	require.Equal(t, faults(42, uids), faults(42, reversed))
+	require.NotEqual(t, faults(42, uids), faults(43, uids))
+}
+
+func TestChaos_Redelivery(t *testing.T) {
+	c := NewChaos(42)
+
+	first := c.draws(&CLIEvent{UID: "1"}).Int63()
+	second := c.draws(&CLIEvent{UID: "1"}).Int63()
+	require.NotEqual(t, first, second, "a redelivered event draws new faults")
+
+	e := &CLIEvent{UID: "2"}
+	require.Same(t, c.draws(e), c.draws(e))
+}
+
+func TestChaos_Timeout(t *testing.T) {
+	d, err := net.NewDispatcher(time.Second, "")
+	require.NoError(t, err)
+
+	c := NewChaos(1)
+	c.TimeoutRate = 1
+	c.TimeoutDelay = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err = c.Forward(context.Background(), d, "http://127.0.0.1:1", &CLIEvent{UID: "1"})
+	require.ErrorIs(t, err, ErrChaosTimeout)
+	require.GreaterOrEqual(t, time.Since(start), c.TimeoutDelay)
+
+	c.TimeoutDelay = time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = c.Forward(ctx, d, "http://127.0.0.1:1", &CLIEvent{UID: "2"})
+	require.ErrorIs(t, err, ErrChaosTimeout)
+}
+
+func TestChaos_HeldPerStream(t *testing.T) {
+	chaos := NewChaos(1)
+	chaos.ReorderRate = 1
+
+	// streams opened for the same listen request get a chaos of their own
+	request := &ListenRequest{ProjectID: "p1", Chaos: chaos}
+	a, b := &stream{}, &stream{}
+	a.setRequest(request)
+	b.setRequest(request)
+
+	first, second := &CLIEvent{UID: "e1"}, &CLIEvent{UID: "e2"}
+	require.Empty(t, a.request.Load().Chaos.Reorder(first, func(*CLIEvent) {}))
+	require.Empty(t, b.request.Load().Chaos.Reorder(second, func(*CLIEvent) {}))
+
+	require.Same(t, first, a.request.Load().Chaos.Release())
+	require.Same(t, second, b.request.Load().Chaos.Release())
+	require.Nil(t, chaos.Release())
+}
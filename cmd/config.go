@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	convoyCli "github.com/frain-dev/convoy-cli"
+	"github.com/frain-dev/convoy-cli/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func addConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the cli config file",
+	}
+
+	cmd.AddCommand(addConfigViewCommand())
+	cmd.AddCommand(addConfigGetCommand())
+	cmd.AddCommand(addConfigSetCommand())
+	cmd.AddCommand(addConfigUnsetCommand())
+	cmd.AddCommand(addConfigPathCommand())
+	cmd.AddCommand(addConfigEditCommand())
+	cmd.AddCommand(addConfigExportCommand())
+	cmd.AddCommand(addConfigImportCommand())
+	cmd.AddCommand(addConfigMigrateCommand())
+
+	return cmd
+}
+
+func addConfigViewCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "view",
+		Short: "Prints the config file with the api keys masked",
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := convoyCli.LoadConfig(overrides)
+			if err != nil {
+				log.Fatal("Error loading config file:", err)
+			}
+
+			view, err := c.View()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Print(string(view))
+		},
+	}
+}
+
+func addConfigGetCommand() *cobra.Command {
+	var reveal bool
+
+	cmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Prints a setting: " + configKeysHelp(),
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := convoyCli.LoadConfig(overrides)
+			if err != nil {
+				log.Fatal("Error loading config file:", err)
+			}
+
+			value, err := c.Get(args[0], reveal)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Println(value)
+		},
+	}
+
+	cmd.Flags().BoolVar(&reveal, "reveal", false, "Print the api key instead of masking it")
+
+	return cmd
+}
+
+func addConfigSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Changes a setting: " + configKeysHelp(),
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			changeConfig(func(c *convoyCli.Config) error {
+				return c.Set(args[0], args[1])
+			})
+
+			if args[0] == convoyCli.KeyApiKey || strings.HasSuffix(args[0], "."+convoyCli.KeyApiKey) {
+				fmt.Printf("Set %s\n", args[0])
+				return
+			}
+			fmt.Printf("Set %s to %s\n", args[0], args[1])
+		},
+	}
+}
+
+func addConfigUnsetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Clears a setting, unsetting an api key deletes it from the secret store",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			changeConfig(func(c *convoyCli.Config) error {
+				return c.Unset(args[0])
+			})
+
+			fmt.Printf("Unset %s\n", args[0])
+		},
+	}
+}
+
+func addConfigPathCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Prints the path of the config file",
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := convoyCli.LoadConfig(convoyCli.ConfigOverrides{Path: overrides.Path})
+			if err != nil {
+				log.Fatal("Error loading config file:", err)
+			}
+
+			fmt.Println(c.Path())
+		},
+	}
+}
+
+func addConfigEditCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "Opens the config file in $EDITOR, it is validated before it is saved",
+		Run: func(cmd *cobra.Command, args []string) {
+			unlock := lockConfig()
+			defer unlock()
+
+			c, err := convoyCli.LoadConfig(convoyCli.ConfigOverrides{Path: overrides.Path, Locked: true})
+			if err != nil {
+				log.Fatal("Error loading config file:", err)
+			}
+
+			data, err := c.Marshal()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			f, err := os.CreateTemp("", "convoy-config-*.yml")
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+
+			err = f.Close()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			reader := bufio.NewReader(os.Stdin)
+			for {
+				err = os.WriteFile(f.Name(), data, 0600)
+				if err != nil {
+					log.Fatal(err)
+				}
+
+				err = runEditor(f.Name())
+				if err != nil {
+					log.Fatal(err)
+				}
+
+				data, err = os.ReadFile(f.Name())
+				if err != nil {
+					log.Fatal(err)
+				}
+
+				err = c.Replace(data)
+				if err == nil {
+					break
+				}
+
+				fmt.Printf("The config is invalid: %v\nEdit it again? [Y/n] ", err)
+				answer, _ := reader.ReadString('\n')
+				if strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "n") {
+					fmt.Println("The config file was not changed")
+					return
+				}
+			}
+
+			err = c.WriteToDisk()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("Saved %s\n", c.Path())
+		},
+	}
+}
+
+func addConfigExportCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Prints the settings that can be shared with your team: the profiles' hosts and the listen defaults, never api keys",
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := convoyCli.LoadConfig(convoyCli.ConfigOverrides{Path: overrides.Path})
+			if err != nil {
+				log.Fatal("Error loading config file:", err)
+			}
+
+			data, err := c.Export()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if util.IsStringEmpty(output) {
+				fmt.Print(string(data))
+				return
+			}
+
+			err = os.WriteFile(output, data, 0644)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("Exported the config to %s\n", output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the export to a file instead of stdout")
+
+	return cmd
+}
+
+func addConfigImportCommand() *cobra.Command {
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Adds the profiles and listen defaults of a config export, run `convoy-cli login --profile <name>` to log into them",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			var imported []string
+			changeConfig(func(c *convoyCli.Config) error {
+				imported, err = c.Import(data, overwrite)
+				return err
+			})
+
+			if len(imported) == 0 {
+				fmt.Println("Imported the listen defaults, every profile already exists")
+				return
+			}
+			fmt.Printf("Imported profiles %s\n", strings.Join(imported, ", "))
+		},
+	}
+
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace profiles that exist with another host, their api key is deleted")
+
+	return cmd
+}
+
+func addConfigMigrateCommand() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrades the config file to the version of this cli, every command also does it when it loads the config",
+		Run: func(cmd *cobra.Command, args []string) {
+			m, err := convoyCli.MigrateConfig(overrides, dryRun)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if m == nil {
+				fmt.Printf("The config file is up to date (version %d)\n", convoyCli.ConfigVersion)
+				return
+			}
+
+			if !dryRun {
+				fmt.Printf("Migrated %s from version %d to %d, the previous file is kept at %s without its api keys\n", m.Path, m.From, m.To, m.Backup())
+				return
+			}
+
+			fmt.Printf("Migrating %s from version %d to %d would change:\n\n", m.Path, m.From, m.To)
+			printDiff(os.Stdout, string(m.Before), string(m.After))
+
+			if len(m.ApiKeys) > 0 {
+				fmt.Printf("\nThe api keys of %s would move to the secret store.\n", strings.Join(m.ApiKeys, ", "))
+			}
+			fmt.Printf("The current file would be kept at %s without its api keys\n", m.Backup())
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would change without writing the config file")
+
+	return cmd
+}
+
+// changeConfig loads the config file without overrides, changes it and
+// writes it back under the config lock.
+func changeConfig(change func(c *convoyCli.Config) error) {
+	unlock := lockConfig()
+	defer unlock()
+
+	c, err := convoyCli.LoadConfig(convoyCli.ConfigOverrides{Path: overrides.Path, Profile: overrides.Profile, Locked: true})
+	if err != nil {
+		log.Fatal("Error loading config file:", err)
+	}
+
+	err = change(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = c.WriteToDisk()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runEditor opens path in $VISUAL or $EDITOR and waits for it to exit.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if util.IsStringEmpty(editor) {
+		editor = os.Getenv("EDITOR")
+	}
+	if util.IsStringEmpty(editor) {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// the editor may have arguments, e.g. EDITOR="code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("failed to run editor %s: %v", editor, err)
+	}
+	return nil
+}
+
+func configKeysHelp() string {
+	return strings.Join(convoyCli.ConfigKeys, ", ") + " or profiles.<name>.<key>"
+}
+
+// printDiff writes the lines removed from before with a - and the lines
+// added in after with a +.
+func printDiff(w io.Writer, before, after string) {
+	a := strings.Split(strings.TrimRight(before, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(after, "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(w, "  %s\n", a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			fmt.Fprintf(w, "+ %s\n", b[j])
+			j++
+		default:
+			fmt.Fprintf(w, "- %s\n", a[i])
+			i++
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	convoyCli "github.com/frain-dev/convoy-cli"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// daemonEnv is set on the listener process started by --detach.
+	daemonEnv = "CONVOY_CLI_DAEMON"
+
+	// Time allowed for a background listener to connect or stop.
+	daemonStartTimeout = 15 * time.Second
+	daemonStopTimeout  = 30 * time.Second
+)
+
+func addListenStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Shows the state of the background listener",
+		Run: func(cmd *cobra.Command, args []string) {
+			status, err := sendDaemonCommand(convoyCli.ControlStatus)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			printListenerStatus(status)
+		},
+	}
+}
+
+func addListenPauseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause",
+		Short: "Stops forwarding events until the background listener is resumed",
+		Run: func(cmd *cobra.Command, args []string) {
+			_, err := sendDaemonCommand(convoyCli.ControlPause)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Println("Background listener paused, run `convoy-cli listen resume` to continue")
+		},
+	}
+}
+
+func addListenResumeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume",
+		Short: "Resumes forwarding events in the background listener",
+		Run: func(cmd *cobra.Command, args []string) {
+			_, err := sendDaemonCommand(convoyCli.ControlResume)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Println("Background listener resumed")
+		},
+	}
+}
+
+func addListenStopCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stops the background listener once in-flight events are delivered",
+		Run: func(cmd *cobra.Command, args []string) {
+			paths, err := convoyCli.DefaultDaemonPaths()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			status, err := convoyCli.SendControl(paths.Socket, convoyCli.ControlStop)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			deadline := time.Now().Add(daemonStopTimeout)
+			for time.Now().Before(deadline) {
+				if _, err = convoyCli.SendControl(paths.Socket, convoyCli.ControlStatus); errors.Is(err, convoyCli.ErrDaemonNotRunning) {
+					fmt.Printf("Background listener (pid %d) stopped\n", status.PID)
+					return
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+
+			log.Fatalf("Background listener (pid %d) did not stop within %v, see %s", status.PID, daemonStopTimeout, paths.Log)
+		},
+	}
+}
+
+func addListenLogsCommand() *cobra.Command {
+	var follow bool
+
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Prints the logs of the background listener",
+		Run: func(cmd *cobra.Command, args []string) {
+			paths, err := convoyCli.DefaultDaemonPaths()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			err = printLog(paths.Log, follow)
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing new log lines as they are written")
+
+	return cmd
+}
+
+func sendDaemonCommand(command string) (*convoyCli.ListenerStatus, error) {
+	paths, err := convoyCli.DefaultDaemonPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	return convoyCli.SendControl(paths.Socket, command)
+}
+
+func printListenerStatus(status *convoyCli.ListenerStatus) {
+	state := "listening"
+	switch {
+	case !status.Ready:
+		state = "connecting"
+	case status.Paused:
+		state = "paused"
+	}
+
+	fmt.Printf("Background listener running (pid %d) for %v, %s\n", status.PID, time.Since(status.Started).Round(time.Second), state)
+	for _, s := range status.Streams {
+		connection := "connected"
+		if !s.Connected {
+			connection = "disconnected"
+		}
+
+		target := s.ForwardTo
+		if target == "" {
+			target = "mock"
+		}
+
+		fmt.Printf("  %s/%s -> %s (%s)\n", s.Project, s.Source, target, connection)
+	}
+	fmt.Println(status.Stats)
+}
+
+// startDetached runs the listen command again in a background process with
+// the same flags, and waits for it to connect.
+func startDetached() error {
+	paths, err := convoyCli.DefaultDaemonPaths()
+	if err != nil {
+		return err
+	}
+
+	if status, err := convoyCli.SendControl(paths.Socket, convoyCli.ControlStatus); err == nil {
+		return fmt.Errorf("a background listener is already running (pid %d), stop it with `convoy-cli listen stop`", status.PID)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(paths.Log, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	args := make([]string, 0, len(os.Args)-1)
+	for _, arg := range os.Args[1:] {
+		if arg == "--detach" || strings.HasPrefix(arg, "--detach=") {
+			continue
+		}
+		args = append(args, arg)
+	}
+
+	c := exec.Command(exe, args...)
+	c.Env = append(os.Environ(), daemonEnv+"=1")
+	c.Stdout = logFile
+	c.Stderr = logFile
+	c.SysProcAttr = detachedProcAttr()
+
+	err = c.Start()
+	if err != nil {
+		return fmt.Errorf("failed to start background listener: %v", err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		_ = c.Wait()
+		close(exited)
+	}()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(daemonStartTimeout)
+
+	for {
+		select {
+		case <-exited:
+			return fmt.Errorf("background listener exited during startup, see %s", paths.Log)
+		case <-timeout:
+			return fmt.Errorf("background listener did not connect within %v, see %s", daemonStartTimeout, paths.Log)
+		case <-ticker.C:
+			status, err := convoyCli.SendControl(paths.Socket, convoyCli.ControlStatus)
+			if err != nil || !status.Ready {
+				continue
+			}
+
+			printListenerStatus(status)
+			fmt.Printf("Logs are written to %s, run `convoy-cli listen stop` to stop the listener\n", paths.Log)
+			return nil
+		}
+	}
+}
+
+// serveDaemon exposes the control socket and pid file of a background
+// listener, the returned function removes them.
+func serveDaemon(l *convoyCli.Listener) (func(), error) {
+	paths, err := convoyCli.DefaultDaemonPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	stop, err := l.ServeControl(paths.Socket)
+	if err != nil {
+		return nil, err
+	}
+
+	err = paths.WritePID()
+	if err != nil {
+		stop()
+		return nil, err
+	}
+
+	return func() {
+		stop()
+		paths.Remove()
+	}, nil
+}
+
+// printLog copies the log file to stdout, with follow it keeps copying
+// lines appended to it until interrupted.
+func printLog(path string, follow bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.New("no background listener logs found, start one with `convoy-cli listen --detach`")
+		}
+		return err
+	}
+	defer f.Close()
+
+	for {
+		_, err = io.Copy(os.Stdout, f)
+		if err != nil || !follow {
+			return err
+		}
+
+		// the log is truncated when a new background listener starts
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+
+		if info, err := os.Stat(path); err == nil && info.Size() < offset {
+			if _, err = f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package main
+
+import "syscall"
+
+const detachedProcess = 0x00000008
+
+// detachedProcAttr starts the background listener without a console, so it
+// outlives the terminal it was started from.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess}
+}
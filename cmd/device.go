@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	convoyCli "github.com/frain-dev/convoy-cli"
+	"github.com/frain-dev/convoy-cli/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func addDeviceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "device",
+		Short: "List, rename or delete the cli devices registered with your Convoy instance",
+	}
+
+	cmd.AddCommand(addDeviceListCommand())
+	cmd.AddCommand(addDeviceRenameCommand())
+	cmd.AddCommand(addDeviceDeleteCommand())
+	cmd.AddCommand(addDevicePruneCommand())
+
+	return cmd
+}
+
+func addDeviceListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Lists the devices of your projects, the ones of this machine are marked with *",
+		Run: func(cmd *cobra.Command, args []string) {
+			c, client := deviceClient()
+
+			devices, err := client.ListDevices()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			ours := c.DeviceIDs()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "\tID\tPROJECT\tNAME\tSTATUS\tLAST SEEN")
+			for _, d := range devices {
+				mark := ""
+				if ours[d.UID] {
+					mark = "*"
+				}
+
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", mark, d.UID, projectName(c, d.ProjectID), d.HostName, d.Status, lastSeen(d.LastSeenAt.Time()))
+			}
+
+			err = w.Flush()
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+}
+
+func addDeviceRenameCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename <id> <name>",
+		Short: "Changes the name the dashboard shows for a device",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			_, client := deviceClient()
+
+			d, err := client.RenameDevice(args[0], args[1])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("Renamed device %s to %s\n", d.UID, d.HostName)
+		},
+	}
+}
+
+func addDeviceDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <id>...",
+		Short: "Deregisters devices, the host stops sending them events",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c, client := deviceClient()
+
+			ours := c.DeviceIDs()
+			for _, id := range args {
+				err := client.DeleteDevice(id)
+				if err != nil {
+					log.Fatal(err)
+				}
+
+				fmt.Printf("Deleted device %s\n", id)
+				if ours[id] {
+					fmt.Println("It was a device of this machine, run `convoy-cli project refresh` to register it again")
+				}
+			}
+		},
+	}
+}
+
+func addDevicePruneCommand() *cobra.Command {
+	var olderThan string
+	var dryRun bool
+	var includeNeverSeen bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Deletes the devices not seen for a while, except the ones of this machine",
+		Long: `Deletes the devices last seen before --older-than, except the ones of this
+machine. Devices that were never seen, e.g. of a login on another machine that
+hasn't listened yet, are kept unless --include-never-seen is set. The devices
+are listed and deleted once you confirm, or right away with --yes.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			age, err := convoyCli.ParseAge(olderThan)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			c, client := deviceClient()
+
+			devices, err := client.ListDevices()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			stale := convoyCli.StaleDevices(devices, time.Now().Add(-age), c.DeviceIDs(), includeNeverSeen)
+			if len(stale) == 0 {
+				fmt.Printf("No device was last seen more than %s ago\n", olderThan)
+				return
+			}
+
+			count := fmt.Sprintf("%d devices", len(stale))
+			if len(stale) == 1 {
+				count = "1 device"
+			}
+
+			fmt.Printf("Found %s last seen more than %s ago:\n", count, olderThan)
+			for _, d := range stale {
+				fmt.Printf("  %s (%s, %s), last seen %s\n", d.UID, projectName(c, d.ProjectID), d.HostName, lastSeen(d.LastSeenAt.Time()))
+			}
+
+			if dryRun {
+				return
+			}
+
+			if !yes {
+				if !term.IsTerminal(int(os.Stdin.Fd())) {
+					log.Fatalf("Pass --yes to delete %s without a prompt", count)
+				}
+
+				answer := prompt(bufio.NewReader(os.Stdin), fmt.Sprintf("Delete %s? (y/N)", count), "")
+				if answer != "y" && answer != "yes" {
+					fmt.Println("No device was deleted")
+					return
+				}
+			}
+
+			for _, d := range stale {
+				err = client.DeleteDevice(d.UID)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Printf("Deleted device %s\n", d.UID)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "30d", "Delete the devices last seen before this age, e.g. 30d or 12h")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the devices that would be deleted without deleting them")
+	cmd.Flags().BoolVar(&includeNeverSeen, "include-never-seen", false, "Also delete the devices that were never seen")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Delete the devices without asking for confirmation")
+
+	return cmd
+}
+
+// deviceClient loads the config and returns a client for the host of the
+// profile in use.
+func deviceClient() (*convoyCli.Config, *convoyCli.Client) {
+	c, err := convoyCli.LoadConfig(overrides)
+	if err != nil {
+		log.Fatal("Error loading config file:", err)
+	}
+
+	if util.IsStringEmpty(c.ActiveApiKey) {
+		log.Fatal("You are not logged in, run `convoy-cli login`")
+	}
+
+	client, err := convoyCli.NewClient(c.Host, c.ActiveApiKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return c, client
+}
+
+// projectName returns the name of a project of the profile in use, or its
+// id when the project list doesn't have it.
+func projectName(c *convoyCli.Config, id string) string {
+	if p := c.FindProject(id); p != nil {
+		return p.Name
+	}
+	return id
+}
+
+func lastSeen(t time.Time) string {
+	if t.Unix() <= 0 {
+		return "never"
+	}
+
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	convoyCli "github.com/frain-dev/convoy-cli"
+	"github.com/frain-dev/convoy-cli/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+type doctorReport struct {
+	OK     bool              `json:"ok"`
+	Checks []convoyCli.Check `json:"checks"`
+}
+
+func addDoctorCommand() *cobra.Command {
+	var asJSON bool
+	var forwardTo string
+	var sourceName string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Checks step by step that the cli can reach your Convoy instance and listen",
+		Long: `Checks the config, DNS, TCP and TLS to the host, its certificate, proxies,
+the api key, the websocket listen connects to, the clock skew with the host,
+the forward target and the host's version. Each failed check has a hint to
+fix it. It exits with status 1 when a check fails.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			// the checks report what they find, the requests' logs only add noise
+			log.SetLevel(log.WarnLevel)
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			d := &convoyCli.Doctor{
+				Overrides:  overrides,
+				ForwardTo:  forwardTo,
+				SourceName: sourceName,
+				Timeout:    timeout,
+			}
+
+			report := printCheck
+			if asJSON {
+				report = nil
+			}
+
+			r := doctorReport{OK: true, Checks: d.Run(ctx, report)}
+			for _, check := range r.Checks {
+				if check.Status == convoyCli.CheckFail {
+					r.OK = false
+				}
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(&r); err != nil {
+					log.Fatal(err)
+				}
+			} else if r.OK {
+				fmt.Println("\nNo problems found")
+			} else {
+				fmt.Println("\nSome checks failed, see the hints above")
+			}
+
+			if !r.OK {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the checks as JSON")
+	cmd.Flags().StringVar(&forwardTo, "forward-to", "", "The forward target to check, defaults to listen.forward_to of the config")
+	cmd.Flags().StringVar(&sourceName, "source-name", "", "The source to check the websocket connection with")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "The timeout of each network check")
+
+	return cmd
+}
+
+func printCheck(check convoyCli.Check) {
+	fmt.Printf("[%s] %-15s %s\n", strings.ToUpper(string(check.Status)), check.Name, check.Detail)
+	if !util.IsStringEmpty(check.Hint) && check.Status != convoyCli.CheckPass {
+		fmt.Printf("       %-15s hint: %s\n", "", check.Hint)
+	}
+}
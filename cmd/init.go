@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	convoyCli "github.com/frain-dev/convoy-cli"
+	"github.com/frain-dev/convoy-cli/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func addInitCommand() *cobra.Command {
+	var project string
+	var sourceName string
+	var forwardTo string
+	var events []string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Writes a .convoy.yml session config for listen in the current directory",
+		Run: func(cmd *cobra.Command, args []string) {
+			r := bufio.NewReader(os.Stdin)
+
+			// the active project is a sensible default, but init works without logging in
+			defaultProject := ""
+			c, err := convoyCli.LoadConfig(overrides)
+			if err == nil {
+				if p := c.FindProject(c.ActiveProjectID); p != nil {
+					defaultProject = p.Name
+				}
+			}
+
+			if !cmd.Flags().Changed("project") {
+				project = prompt(r, "Project id or name", defaultProject)
+			}
+
+			if c != nil && !util.IsStringEmpty(project) && c.FindProject(project) == nil {
+				log.Warnf("project %s not found, run `convoy-cli project refresh` if it was created recently", project)
+			}
+
+			if !cmd.Flags().Changed("source-name") {
+				sourceName = prompt(r, "Source name", "")
+			}
+
+			if util.IsStringEmpty(sourceName) {
+				log.Fatal("source name cannot be empty")
+			}
+
+			if !cmd.Flags().Changed("forward-to") {
+				forwardTo = prompt(r, "Forward events to", "http://localhost:8080/webhooks")
+			}
+
+			if !cmd.Flags().Changed("events") {
+				for _, e := range strings.Split(prompt(r, "Event types to forward, comma separated (e.g. invoice.*)", "all"), ",") {
+					e = strings.TrimSpace(e)
+					if e != "" && e != "all" {
+						events = append(events, e)
+					}
+				}
+			}
+
+			stream := convoyCli.StreamConfig{Source: sourceName, ForwardTo: forwardTo, Events: events}
+			if _, err = stream.EventFilter(); err != nil {
+				log.Fatal(err)
+			}
+
+			session := &convoyCli.SessionConfig{
+				Project: project,
+				Streams: []convoyCli.StreamConfig{stream},
+			}
+
+			err = convoyCli.WriteSessionConfig(convoyCli.SessionConfigName, session, force)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("Wrote %s, run `convoy-cli listen` in this directory or below to start listening\n", convoyCli.SessionConfigName)
+		},
+	}
+
+	cmd.Flags().StringVar(&project, "project", "", "The id or name of the project to listen to")
+	cmd.Flags().StringVar(&sourceName, "source-name", "", "The name of the source to receive events from")
+	cmd.Flags().StringVar(&forwardTo, "forward-to", "", "The host/web server events are forwarded to")
+	cmd.Flags().StringSliceVar(&events, "events", nil, "Event types to forward, glob patterns are allowed (e.g. invoice.*)")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing .convoy.yml")
+
+	return cmd
+}
+
+// prompt asks for a value on stdin, an empty answer picks the default.
+func prompt(r *bufio.Reader, question, defaultValue string) string {
+	if util.IsStringEmpty(defaultValue) {
+		fmt.Printf("%s: ", question)
+	} else {
+		fmt.Printf("%s [%s]: ", question, defaultValue)
+	}
+
+	answer, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		log.Fatal(err)
+	}
+
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return defaultValue
+	}
+	return answer
+}
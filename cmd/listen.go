@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/url"
+	"os"
 	"time"
 
 	convoyCli "github.com/frain-dev/convoy-cli"
@@ -13,25 +16,178 @@ import (
 
 func addListenCommand() *cobra.Command {
 	var since string
-	var sourceName string
+	var projects []string
+	var sourceNames []string
 	// var events string
-	var forwardTo string
+	var forwardTos []string
+	var sessionFile string
+	var mockStatus int
+	var mockRate float64
+	var mockRules string
+	var eventTypeField string
+	var chaosSeed int64
+	var chaosLatency string
+	var chaosTimeoutRate float64
+	var chaosTimeoutDelay time.Duration
+	var chaosDuplicateRate float64
+	var chaosReorderRate float64
+	var chaosCorruptRate float64
+	var dedupe bool
+	var dedupeTTL time.Duration
+	var forceDelivery bool
+	var untilCount int
+	var untilExpr string
+	var untilOutput string
+	var timeout time.Duration
+	var statsInterval time.Duration
+	var showTimings bool
+	var drainTimeout time.Duration
+	var metricsAddr string
+	var traceExporter string
+	var traceEndpoint string
+	var traceFile string
+	var detach bool
 
 	cmd := &cobra.Command{
 		Use:   "listen",
 		Short: "Starts a websocket client that listens to events streamed by the server",
 		Run: func(cmd *cobra.Command, args []string) {
-			c, err := convoyCli.LoadConfig()
+			if detach {
+				err := startDetached()
+				if err != nil {
+					log.Fatal(err)
+				}
+				return
+			}
+
+			var err error
+			var mock *convoyCli.MockConfig
+			if cmd.Flags().Changed("mock-status") || !util.IsStringEmpty(mockRules) {
+				mock = convoyCli.NewMockConfig(mockStatus, mockRate)
+
+				if !util.IsStringEmpty(mockRules) {
+					err = mock.LoadMockRules(mockRules)
+				} else {
+					err = mock.Validate()
+				}
+
+				if err != nil {
+					log.Fatal("Error loading mock rules: ", err)
+				}
+			}
+
+			var chaos *convoyCli.Chaos
+			if !util.IsStringEmpty(chaosLatency) || chaosTimeoutRate > 0 || chaosDuplicateRate > 0 ||
+				chaosReorderRate > 0 || chaosCorruptRate > 0 {
+				if !cmd.Flags().Changed("chaos-seed") {
+					chaosSeed = time.Now().UnixNano()
+				}
+
+				chaos = convoyCli.NewChaos(chaosSeed)
+				chaos.TimeoutRate = chaosTimeoutRate
+				chaos.TimeoutDelay = chaosTimeoutDelay
+				chaos.DuplicateRate = chaosDuplicateRate
+				chaos.ReorderRate = chaosReorderRate
+				chaos.CorruptRate = chaosCorruptRate
+
+				if !util.IsStringEmpty(chaosLatency) {
+					chaos.Latency, err = convoyCli.ParseLatencyDistribution(chaosLatency)
+					if err != nil {
+						log.Fatal("Error parsing chaos latency: ", err)
+					}
+				}
+
+				if err = chaos.Validate(); err != nil {
+					log.Fatal(err)
+				}
+
+				log.Printf("chaos enabled, rerun with --chaos-seed %d to reproduce this session", chaosSeed)
+			}
+
+			var ledger *convoyCli.Ledger
+			if dedupe {
+				path, err := convoyCli.DefaultLedgerPath()
+				if err != nil {
+					log.Fatal(err)
+				}
+
+				ledger, err = convoyCli.OpenLedger(path, dedupeTTL)
+				if err != nil {
+					log.Fatal("Error opening dedupe ledger: ", err)
+				}
+			}
+
+			var until *convoyCli.Until
+			if untilCount > 0 || !util.IsStringEmpty(untilExpr) || timeout > 0 {
+				until = &convoyCli.Until{Count: untilCount, Timeout: timeout, Output: untilOutput}
+
+				// --until waits for one matching event unless --until-count says otherwise,
+				// a bare --timeout only bounds how long the session runs
+				if until.Count == 0 && !util.IsStringEmpty(untilExpr) {
+					until.Count = 1
+				}
+
+				if !util.IsStringEmpty(untilExpr) {
+					until.Match, err = convoyCli.ParseExpression(untilExpr)
+					if err != nil {
+						log.Fatal(err)
+					}
+				}
+
+				if err = until.Validate(); err != nil {
+					log.Fatal(err)
+				}
+			}
+
+			if util.IsStringEmpty(sessionFile) {
+				sessionFile, err = convoyCli.FindSessionConfig(".")
+				if err != nil {
+					log.Fatal("Error looking for a session config: ", err)
+				}
+			}
+
+			session := &convoyCli.SessionConfig{}
+			if !util.IsStringEmpty(sessionFile) {
+				session, err = convoyCli.LoadSessionConfig(sessionFile)
+				if err != nil {
+					log.Fatal("Error loading session config: ", err)
+				}
+				log.Printf("using session config %s", sessionFile)
+			}
+
+			c, err := loadListenConfig(sessionFile)
 			if err != nil {
 				log.Fatal("Error loading config file:", err)
 			}
 
-			if util.IsStringEmpty(forwardTo) {
-				log.Fatal("flag forward-to cannot be empty")
+			flagStreams, err := convoyCli.StreamsFromFlags(projects, sourceNames, forwardTos)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			for i := range flagStreams {
+				flagStreams[i].Since = since
 			}
 
-			if util.IsStringEmpty(sourceName) {
-				log.Fatal("flag source-name cannot be empty")
+			// options that don't depend on the stream
+			options := &convoyCli.ListenRequest{
+				Mock:  mock,
+				Chaos: chaos,
+
+				EventTypeField: eventTypeField,
+
+				Ledger:        ledger,
+				ForceDelivery: forceDelivery,
+
+				Until:         until,
+				StatsInterval: statsInterval,
+				ShowTimings:   showTimings,
+				DrainTimeout:  drainTimeout,
+			}
+
+			listenRequests, err := buildListenRequests(c, session.Resolve(flagStreams), options)
+			if err != nil {
+				log.Fatal(err)
 			}
 
 			hostInfo, err := url.Parse(c.Host)
@@ -39,50 +195,228 @@ func addListenCommand() *cobra.Command {
 				log.Fatal("Error parsing host URL: ", err)
 			}
 
-			if !util.IsStringEmpty(since) {
-				var sinceTime time.Time
-				sinceTime, err = time.Parse(time.RFC3339, since)
+			shutdownTracing := func() {}
+			if !util.IsStringEmpty(traceExporter) {
+				if traceExporter == convoyCli.TraceExporterFile && util.IsStringEmpty(traceFile) {
+					log.Fatal("flag trace-file cannot be empty when exporting traces to a file")
+				}
+
+				shutdown, err := convoyCli.SetupTracing(traceExporter, traceEndpoint, traceFile)
 				if err != nil {
-					log.WithError(err).Error("since is not a valid timestamp, will try time duration")
+					log.Fatal("Error setting up tracing: ", err)
+				}
 
-					dur, err := time.ParseDuration(since)
-					if err != nil {
-						log.WithError(err).Fatal("since is neither a valid time duration or timestamp, see the listen command help menu for a valid since value")
-					} else {
-						since = fmt.Sprintf("since|duration|%v", since)
-						sinceTime = time.Now().Add(-dur)
+				shutdownTracing = func() {
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+
+					if err := shutdown(ctx); err != nil {
+						log.WithError(err).Error("failed to flush traces")
 					}
-				} else {
-					since = fmt.Sprintf("since|timestamp|%v", since)
 				}
+			}
 
-				log.Printf("will resend all discarded events after: %v", sinceTime)
+			l := convoyCli.NewListener(c)
+			if !util.IsStringEmpty(metricsAddr) {
+				err = l.ServeMetrics(metricsAddr)
+				if err != nil {
+					log.Fatal("Error starting metrics server: ", err)
+				}
 			}
 
-			p := FindProjectById(c.Projects, c.ActiveProjectID)
+			if !util.IsStringEmpty(sessionFile) {
+				l.SetReloader(func() (*convoyCli.Config, []*convoyCli.ListenRequest, error) {
+					c, err := loadListenConfig(sessionFile)
+					if err != nil {
+						return nil, nil, err
+					}
 
-			if p == nil {
-				log.Fatal("Active Project not found\nRun `convoy-cli project --switch-to {project_id}` to switch to a valid project")
+					session, err := convoyCli.LoadSessionConfig(sessionFile)
+					if err != nil {
+						return nil, nil, err
+					}
+
+					listenRequests, err := buildListenRequests(c, session.Resolve(flagStreams), options)
+					return c, listenRequests, err
+				})
+
+				err = l.WatchConfig(sessionFile, c.Path())
+				if err != nil {
+					log.Fatal("Error watching the session config: ", err)
+				}
 			}
 
-			listenRequest := convoyCli.ListenRequest{
-				HostName:   c.Host,
-				ProjectID:  c.ActiveProjectID,
-				DeviceID:   p.DeviceID,
-				SourceName: sourceName,
-				Since:      since,
-				ForwardTo:  forwardTo,
+			stopDaemon := func() {}
+			if !util.IsStringEmpty(os.Getenv(daemonEnv)) {
+				stopDaemon, err = serveDaemon(l)
+				if err != nil {
+					log.Fatal("Error starting control socket: ", err)
+				}
 			}
 
-			l := convoyCli.NewListener(c)
-			l.Listen(&listenRequest, hostInfo)
+			err = l.ListenAll(listenRequests, hostInfo)
+			stopDaemon()
+			shutdownTracing()
+			if err != nil {
+				log.Fatal(err)
+			}
 		},
 	}
 
-	cmd.Flags().StringVar(&sourceName, "source-name", "", "The name of the source you want to receive events from (only applies to incoming projects)")
+	cmd.Flags().StringArrayVar(&projects, "project", nil, "The id or name of a project to listen to, repeat to listen to several projects (defaults to the active project)")
+	cmd.Flags().StringArrayVar(&sourceNames, "source-name", nil, "The name of the source you want to receive events from (only applies to incoming projects), repeat for several sources")
 	cmd.Flags().StringVar(&since, "since", "", "Send discarded events since a timestamp (e.g. 2013-01-02T13:23:37Z) or relative time (e.g. 42m for 42 minutes)")
-	cmd.Flags().StringVar(&forwardTo, "forward-to", "", "The host/web server you want to forward events to")
+	cmd.Flags().StringArrayVar(&forwardTos, "forward-to", nil, "The host/web server you want to forward events to, repeat to give each --project/--source-name its own target")
+	cmd.Flags().StringVar(&sessionFile, "session", "", "Path to a session config listing the streams to listen to, defaults to the nearest .convoy.yml (see convoy-cli init), flags override its values. Edits and SIGHUP reload it without reconnecting")
+	cmd.Flags().IntVar(&mockStatus, "mock-status", 0, "Answer events with this status code instead of forwarding them")
+	cmd.Flags().Float64Var(&mockRate, "mock-rate", 1, "Fraction of events (0-1) answered with the mock status, the rest are answered with 200. 0 answers every event with 200")
+	cmd.Flags().StringVar(&mockRules, "mock-rules", "", "Path to a yaml file of mock rules keyed by event type, the server doesn't send the type so set --event-type-field for them to match")
+	cmd.Flags().StringVar(&eventTypeField, "event-type-field", "", "Path of the event type in events, e.g. data.type or headers.X-Event-Type, Convoy doesn't stream the type of events")
+	cmd.Flags().Int64Var(&chaosSeed, "chaos-seed", 0, "Seed for the chaos faults, reuse a logged seed to reproduce a session")
+	cmd.Flags().StringVar(&chaosLatency, "chaos-latency", "", "Latency added before forwarding (e.g. 500ms, uniform:100ms-2s, normal:500ms,100ms, exp:300ms)")
+	cmd.Flags().Float64Var(&chaosTimeoutRate, "chaos-timeout-rate", 0, "Fraction of events (0-1) dropped with a simulated timeout")
+	cmd.Flags().DurationVar(&chaosTimeoutDelay, "chaos-timeout-delay", 0, "How long a simulated timeout waits before it fails, defaults to the forward timeout")
+	cmd.Flags().Float64Var(&chaosDuplicateRate, "chaos-duplicate-rate", 0, "Fraction of events (0-1) forwarded twice")
+	cmd.Flags().Float64Var(&chaosReorderRate, "chaos-reorder-rate", 0, "Fraction of events (0-1) held back and forwarded after the next event")
+	cmd.Flags().Float64Var(&chaosCorruptRate, "chaos-corrupt-rate", 0, "Fraction of events (0-1) forwarded with a corrupted payload")
+	cmd.Flags().BoolVar(&dedupe, "dedupe", false, "Acknowledge events already delivered to the target without forwarding them again")
+	cmd.Flags().DurationVar(&dedupeTTL, "dedupe-ttl", 24*time.Hour, "How long a delivered event is remembered for deduplication")
+	cmd.Flags().BoolVar(&forceDelivery, "force-delivery", false, "Deliver duplicate events anyway when --dedupe is set, e.g. to test idempotency")
+	cmd.Flags().IntVar(&untilCount, "until-count", 0, "Exit once this many events (matching --until, if set) were forwarded successfully")
+	cmd.Flags().StringVar(&untilExpr, "until", "", `Exit once an event matching this condition was forwarded successfully (e.g. 'data.status == "paid"')`)
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "Exit with a non-zero status if the --until condition is not met within this duration, without --until/--until-count stop listening after it")
+	cmd.Flags().StringVar(&untilOutput, "until-output", "", "Write the events counted by --until/--until-count to this file as json")
+	cmd.Flags().DurationVar(&statsInterval, "stats-interval", 0, "Log a line of session statistics at this interval (e.g. 30s)")
+	cmd.Flags().BoolVar(&showTimings, "timings", false, "Print a breakdown of dns, connect, tls, server processing and transfer time for each forwarded event")
+	cmd.Flags().DurationVar(&drainTimeout, "drain-timeout", 10*time.Second, "How long to wait for in-flight events to be delivered on shutdown, a second signal exits immediately")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve prometheus metrics on /metrics and health checks on /healthz and /readyz at this address (e.g. :9090)")
+	cmd.Flags().StringVar(&traceExporter, "trace-exporter", "", "Record opentelemetry spans for each event and export them with otlp or to a file")
+	cmd.Flags().StringVar(&traceEndpoint, "trace-endpoint", "", "OTLP/HTTP collector address (e.g. localhost:4318), defaults to OTEL_EXPORTER_OTLP_ENDPOINT")
+	cmd.Flags().StringVar(&traceFile, "trace-file", "", "File spans are written to when --trace-exporter is file")
+	cmd.Flags().BoolVar(&detach, "detach", false, "Run the listener in the background, see the listen status, logs, pause, resume and stop commands")
 	// cmd.Flags().StringVar(&events, "events", "*", "Events types")
 
+	cmd.AddCommand(addListenStatusCommand())
+	cmd.AddCommand(addListenLogsCommand())
+	cmd.AddCommand(addListenPauseCommand())
+	cmd.AddCommand(addListenResumeCommand())
+	cmd.AddCommand(addListenStopCommand())
+
 	return cmd
 }
+
+// loadListenConfig loads the config with the session file as project file.
+// A config without projects, e.g. in CI with only CONVOY_HOST and
+// CONVOY_API_KEY set, gets them from the host without writing them to disk.
+func loadListenConfig(sessionFile string) (*convoyCli.Config, error) {
+	o := overrides
+	o.ProjectFile = sessionFile
+
+	c, err := convoyCli.LoadConfig(o)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.Projects) == 0 && !util.IsStringEmpty(c.ActiveApiKey) {
+		response, err := fetchProjects(c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the projects of %s: %v", c.Host, err)
+		}
+		c.SetProjects(response)
+	}
+
+	return c, nil
+}
+
+// buildListenRequests returns the listen request of each stream, with the
+// options given by flags copied from options.
+func buildListenRequests(c *convoyCli.Config, streams []convoyCli.StreamConfig, options *convoyCli.ListenRequest) ([]*convoyCli.ListenRequest, error) {
+	listenRequests := make([]*convoyCli.ListenRequest, 0, len(streams))
+	for _, stream := range streams {
+		// the config's listen defaults come after flags and the session config
+		if c.Listen != nil {
+			if util.IsStringEmpty(stream.ForwardTo) {
+				stream.ForwardTo = c.Listen.ForwardTo
+			}
+			if util.IsStringEmpty(stream.Since) {
+				stream.Since = c.Listen.Since
+			}
+		}
+
+		if util.IsStringEmpty(stream.ForwardTo) && options.Mock == nil {
+			return nil, errors.New("flag forward-to cannot be empty")
+		}
+
+		if util.IsStringEmpty(stream.Source) {
+			return nil, errors.New("flag source-name cannot be empty")
+		}
+
+		var p *convoyCli.ConfigProject
+		if util.IsStringEmpty(stream.Project) {
+			p = c.FindProject(c.ActiveProjectID)
+			if p == nil {
+				return nil, errors.New("Active Project not found\nRun `convoy-cli project use` to switch to a valid project")
+			}
+		} else {
+			p = c.FindProject(stream.Project)
+			if p == nil {
+				return nil, fmt.Errorf("project %s not found\nRun `convoy-cli project refresh` to refresh the project list", stream.Project)
+			}
+		}
+
+		filter, err := stream.EventFilter()
+		if err != nil {
+			return nil, err
+		}
+
+		since, err := parseSince(stream.Since)
+		if err != nil {
+			return nil, err
+		}
+
+		listenRequest := *options
+		listenRequest.HostName = c.Host
+		listenRequest.ProjectID = p.UID
+		listenRequest.ProjectName = p.Name
+		listenRequest.DeviceID = p.DeviceID
+		listenRequest.SourceName = stream.Source
+		listenRequest.Since = since
+		listenRequest.ForwardTo = stream.ForwardTo
+		listenRequest.Filter = filter
+		listenRequest.Headers = stream.Headers
+		listenRequest.Transform = stream.Transform
+
+		// --event-type-field overrides the stream's
+		if util.IsStringEmpty(listenRequest.EventTypeField) {
+			listenRequest.EventTypeField = stream.EventTypeField
+		}
+
+		listenRequests = append(listenRequests, &listenRequest)
+	}
+
+	return listenRequests, nil
+}
+
+// parseSince turns a --since timestamp or duration into the message asking
+// the server to resend discarded events.
+func parseSince(since string) (string, error) {
+	if util.IsStringEmpty(since) {
+		return "", nil
+	}
+
+	sinceTime, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		dur, err := time.ParseDuration(since)
+		if err != nil {
+			return "", errors.New("since is neither a valid time duration or timestamp, see the listen command help menu for a valid since value")
+		}
+
+		since = fmt.Sprintf("since|duration|%v", since)
+		sinceTime = time.Now().Add(-dur)
+	} else {
+		since = fmt.Sprintf("since|timestamp|%v", since)
+	}
+
+	log.Printf("will resend all discarded events after: %v", sinceTime)
+	return since, nil
+}
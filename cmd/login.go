@@ -1,86 +1,109 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
-	"net"
-	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
 	"time"
 
 	convoyCli "github.com/frain-dev/convoy-cli"
-	convoyNet "github.com/frain-dev/convoy-cli/net"
 	"github.com/frain-dev/convoy-cli/util"
 	"github.com/spf13/cobra"
 )
 
+// defaultHost is the host logged into when neither a flag, the environment
+// nor the profile sets one.
+const defaultHost = "https://cli.getconvoy.io"
+
+// Web login flows, see convoyCli.WebLogin.
+const (
+	webLoginCallback   = "callback"
+	webLoginDeviceCode = "device-code"
+)
+
 func addLoginCommand() *cobra.Command {
-	var apiKey string
-	var host string
+	var secretStore string
+	var web bool
+	var deviceCode bool
 
 	cmd := &cobra.Command{
 		Use:   "login",
-		Short: "Logs into your Convoy instance using a Personal API Key",
+		Short: "Logs into your Convoy instance in the browser with --web, or using a Personal API Key given with --host and --api-key",
 		Run: func(cmd *cobra.Command, args []string) {
-			err := login(host, apiKey, true)
+			flow := ""
+			if web || deviceCode {
+				if !util.IsStringEmpty(overrides.ApiKey) {
+					log.Fatal("--web and --device-code log in without an api key, don't pass --api-key")
+				}
+
+				flow = webLoginCallback
+				if deviceCode || !hasBrowser() {
+					flow = webLoginDeviceCode
+				}
+			}
+
+			err := login(secretStore, flow, true)
 			if err != nil {
 				log.Fatal(err)
 			}
 		},
 	}
 
-	cmd.Flags().StringVar(&apiKey, "api-key", "", "API Key")
-	cmd.Flags().StringVar(&host, "host", "https://cli.getconvoy.io", "Host")
+	cmd.Flags().StringVar(&secretStore, "secret-store", "", "Where to store API keys: keyring (the OS keyring) or file (a file encrypted with a passphrase, see CONVOY_CLI_PASSPHRASE and CONVOY_CLI_KEY_FILE). Defaults to the keyring when it is available")
+	cmd.Flags().BoolVar(&web, "web", false, "Log in by authorizing the cli in the dashboard, the cli receives a token scoped to this device")
+	cmd.Flags().BoolVar(&deviceCode, "device-code", false, "Log in with a code entered in the dashboard on another device, for machines without a browser. Used by --web when no browser is found")
 
 	return cmd
 }
 
-func login(host, apiKey string, isLogin bool) error {
-	c, err := convoyCli.NewConfig(host, apiKey)
-	if err != nil {
-		return err
-	}
-
-	if util.IsStringEmpty(c.Host) {
-		return errors.New("host is required")
-	}
-
-	if util.IsStringEmpty(c.ActiveApiKey) {
-		return errors.New("api key is required")
+// login logs into the host, or refreshes the project list when isLogin is
+// false. With a web login flow, the token it receives is the api key.
+func login(secretStore, webFlow string, isLogin bool) error {
+	o := overrides
+	machineID := ""
+	if !util.IsStringEmpty(webFlow) {
+		token, id, err := webLogin(webFlow)
+		if err != nil {
+			return err
+		}
+		o.ApiKey, machineID = token.Token, id
 	}
 
-	hostName, err := generateDeviceHostName()
+	unlock, err := convoyCli.LockConfig(o)
 	if err != nil {
 		return err
 	}
+	defer unlock()
+	o.Locked = true
 
-	loginRequest := &convoyCli.LoginRequest{HostName: hostName}
-	body, err := json.Marshal(loginRequest)
+	c, err := convoyCli.NewConfig(o)
 	if err != nil {
 		return err
 	}
 
-	var response *convoyCli.LoginResponse
+	if !util.IsStringEmpty(secretStore) {
+		c.SecretStore = secretStore
+	}
 
-	dispatch, err := convoyNet.NewDispatcher(time.Second*10, "")
-	if err != nil {
-		return err
+	// the token of a web login is scoped to the host name with this id
+	if util.IsStringEmpty(c.MachineID) {
+		c.MachineID = machineID
 	}
 
-	url := fmt.Sprintf("%s/stream/login", c.Host)
-	resp, err := dispatch.SendCliRequest(url, http.MethodPost, c.ActiveApiKey, body)
-	if err != nil {
-		return err
+	if util.IsStringEmpty(c.Host) {
+		c.Host = defaultHost
 	}
 
-	if resp.StatusCode != 200 {
-		return errors.New(string(resp.Body))
+	if util.IsStringEmpty(c.ActiveApiKey) {
+		return errors.New("api key is required, pass --api-key or log in in the browser with --web")
 	}
 
-	err = json.Unmarshal(resp.Body, &response)
+	response, err := fetchProjects(c)
 	if err != nil {
 		return err
 	}
@@ -93,7 +116,8 @@ func login(host, apiKey string, isLogin bool) error {
 	if isLogin {
 		fmt.Println("Login Success!")
 		fmt.Println("Name:", response.UserName)
-		fmt.Println("Host:", host)
+		fmt.Println("Host:", c.Host)
+		fmt.Println("Profile:", c.ProfileName())
 		return nil
 	}
 
@@ -101,36 +125,106 @@ func login(host, apiKey string, isLogin bool) error {
 	return nil
 }
 
-// generateDeviceHostName uses the machine's host name and the mac address to generate a predictable unique id per device
-func generateDeviceHostName() (string, error) {
-	name, err := os.Hostname()
+// webLogin authorizes the cli in the dashboard of the host being logged
+// into and returns the token with the machine id of the host name it is
+// scoped to. It runs before the config lock is taken, as the user may take
+// minutes to approve it.
+func webLogin(flow string) (*convoyCli.WebLoginToken, string, error) {
+	c, err := convoyCli.NewConfig(overrides)
 	if err != nil {
-		return "", err
+		return nil, "", err
+	}
+
+	host := c.Host
+	if util.IsStringEmpty(host) {
+		host = defaultHost
 	}
 
-	interfaces, err := net.Interfaces()
+	hostName, err := generateDeviceHostName(c)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
 
-	var mac uint64
-	for _, i := range interfaces {
-		if i.Flags&net.FlagUp != 0 && !bytes.Equal(i.HardwareAddr, nil) {
+	w, err := convoyCli.NewWebLogin(host, hostName, os.Stdout)
+	if err != nil {
+		return nil, "", err
+	}
+	w.OpenURL = openBrowser
 
-			// Skip virtual MAC addresses (Locally Administered Addresses).
-			if i.HardwareAddr[0]&2 == 2 {
-				continue
-			}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-			for j, b := range i.HardwareAddr {
-				if j >= 8 {
-					break
-				}
-				mac <<= 8
-				mac += uint64(b)
-			}
-		}
+	var token *convoyCli.WebLoginToken
+	if flow == webLoginDeviceCode {
+		token, err = w.DeviceCode(ctx)
+	} else {
+		token, err = w.Callback(ctx)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !token.ExpiresAt.IsZero() {
+		fmt.Printf("The token expires at %s, run login again then\n", token.ExpiresAt.Local().Format(time.RFC1123))
+	}
+
+	return token, c.MachineID, nil
+}
+
+// hasBrowser reports whether a browser can likely be opened: not over ssh,
+// and on linux only with a display.
+func hasBrowser() bool {
+	if !util.IsStringEmpty(os.Getenv("SSH_CONNECTION")) {
+		return false
+	}
+
+	if runtime.GOOS == "linux" {
+		return !util.IsStringEmpty(os.Getenv("DISPLAY")) || !util.IsStringEmpty(os.Getenv("WAYLAND_DISPLAY"))
+	}
+
+	return true
+}
+
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	return cmd.Start()
+}
+
+// fetchProjects registers this device with the host and returns the
+// projects the api key has access to. The host finds the device of each
+// project by its host name, or creates it.
+func fetchProjects(c *convoyCli.Config) (*convoyCli.LoginResponse, error) {
+	hostName, err := generateDeviceHostName(c)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := convoyCli.NewClient(c.Host, c.ActiveApiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	response, _, err := client.Login(&convoyCli.LoginRequest{HostName: hostName})
+	return response, err
+}
+
+// generateDeviceHostName uses the machine's host name and the machine id of
+// the config to generate a predictable unique name per device. Unlike a mac
+// address the id doesn't change with docking stations, VMs or containers.
+func generateDeviceHostName(c *convoyCli.Config) (string, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return "", err
 	}
 
-	return fmt.Sprintf("%v-%v", name, mac), nil
+	return fmt.Sprintf("%v-%v", name, c.EnsureMachineID()), nil
 }
@@ -1,22 +1,104 @@
 package main
 
 import (
+	"fmt"
+
 	cli "github.com/frain-dev/convoy-cli"
+	"github.com/frain-dev/convoy-cli/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 func addLogoutCommand() *cobra.Command {
+	var all bool
+	var revoke bool
+
 	cmd := &cobra.Command{
 		Use:   "logout",
-		Short: "Logs out of your Convoy instance",
+		Short: "Logs out of your Convoy instance, its devices are deregistered from the host",
+		Long: `Logs out of the active profile, or of the one given with --profile or --host
+(or $CONVOY_PROFILE or $CONVOY_HOST).
+The devices of the profile are deregistered from the host, so it stops sending
+them events, and with --revoke its api key is revoked. With --all every profile
+is logged out and the config file is deleted.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := cli.DeleteConfigFile()
+			unlock := lockConfig()
+			defer unlock()
+
+			if all {
+				logoutAll(revoke)
+				return
+			}
+
+			c, err := cli.LoadConfig(cli.ConfigOverrides{Path: overrides.Path, Locked: true})
+			if err != nil {
+				log.Fatal("Error loading config file:", err)
+			}
+
+			name, err := c.SelectProfile(overrides)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			err = c.UseProfile(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			deregister(c, revoke)
+
+			host := c.Host
+			err = c.DeleteProfile(name)
 			if err != nil {
 				log.Fatal(err)
 			}
+
+			err = c.WriteToDisk()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("Logged out of profile %s (%s)\n", name, host)
+			if !util.IsStringEmpty(c.ActiveProfile) {
+				fmt.Printf("The active profile is %s\n", c.ActiveProfile)
+			}
 		},
 	}
 
+	cmd.Flags().BoolVar(&all, "all", false, "Log out of every profile and delete the config file")
+	cmd.Flags().BoolVar(&revoke, "revoke", false, "Also revoke the api key on the host")
+
 	return cmd
 }
+
+// logoutAll deregisters every profile and deletes the config file.
+func logoutAll(revoke bool) {
+	c, err := cli.LoadConfig(cli.ConfigOverrides{Path: overrides.Path, Locked: true})
+	if err == nil {
+		for _, name := range c.ProfileNames() {
+			if err = c.UseProfile(name); err != nil {
+				log.WithError(err).Warnf("profile %s can't be deregistered", name)
+				continue
+			}
+			deregister(c, revoke)
+		}
+	}
+
+	o := overrides
+	o.Locked = true
+	err = cli.DeleteConfigFile(o)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("Logged out of every profile")
+}
+
+// deregister deregisters the profile in use from its host, the local logout
+// goes ahead when the host can't be reached.
+func deregister(c *cli.Config, revoke bool) {
+	err := c.Deregister(revoke)
+	if err != nil {
+		log.Warnf("%v\nThe cli is logged out locally, remove them in the dashboard", err)
+	}
+}
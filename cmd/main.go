@@ -8,6 +8,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// overrides are the config values set by the global flags.
+var overrides convoyCli.ConfigOverrides
+
 func main() {
 	err := os.Setenv("TZ", "") // Use UTC by default :)
 	if err != nil {
@@ -20,11 +23,22 @@ func main() {
 		Short:   "Client CLI for debugging your events locally",
 	}
 
+	cmd.PersistentFlags().StringVar(&overrides.Path, "config", "", "Path to the config file, defaults to $CONVOY_CONFIG or ~/.convoy/config")
+	cmd.PersistentFlags().StringVar(&overrides.Profile, "profile", "", "The config profile to use, defaults to $CONVOY_PROFILE or the active profile (see convoy-cli profile)")
+	cmd.PersistentFlags().StringVar(&overrides.Host, "host", "", "The Convoy host, defaults to $CONVOY_HOST or the host of the profile")
+	cmd.PersistentFlags().StringVar(&overrides.ApiKey, "api-key", "", "The personal API key, defaults to $CONVOY_API_KEY or the key of the profile")
+	cmd.PersistentFlags().StringVar(&overrides.ProjectID, "project", "", "The id or name of the project, defaults to $CONVOY_PROJECT_ID, the project in .convoy.yml or the active project")
+
 	cmd.AddCommand(addListenCommand())
 	cmd.AddCommand(addLoginCommand())
 	cmd.AddCommand(addProjectCommand())
 	cmd.AddCommand(addLogoutCommand())
 	cmd.AddCommand(addStatusCommand())
+	cmd.AddCommand(addInitCommand())
+	cmd.AddCommand(addProfileCommand())
+	cmd.AddCommand(addConfigCommand())
+	cmd.AddCommand(addDeviceCommand())
+	cmd.AddCommand(addDoctorCommand())
 
 	err = cmd.Execute()
 	if err != nil {
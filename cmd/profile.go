@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+
+	convoyCli "github.com/frain-dev/convoy-cli"
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func addProfileCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "List, switch, rename or delete the profiles of your Convoy instances",
+	}
+
+	cmd.AddCommand(addProfileListCommand())
+	cmd.AddCommand(addProfileUseCommand())
+	cmd.AddCommand(addProfileRenameCommand())
+	cmd.AddCommand(addProfileDeleteCommand())
+
+	return cmd
+}
+
+func addProfileListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Lists your profiles",
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := convoyCli.ReadConfig(convoyCli.ConfigOverrides{Path: overrides.Path})
+			if err != nil {
+				log.Fatal("Error loading config file:", err)
+			}
+
+			t := newTable("", "NAME", "HOST", "PROJECTS")
+			for _, name := range c.ProfileNames() {
+				active := ""
+				if name == c.ActiveProfile {
+					active = "*"
+				}
+
+				p := c.Profiles[name]
+				t.AppendRow(table.Row{active, name, p.Host, len(p.Projects)})
+			}
+			t.Render()
+		},
+	}
+}
+
+func addProfileUseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use <name>",
+		Short: "Makes a profile the active profile",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			unlock := lockConfig()
+			defer unlock()
+
+			c, err := convoyCli.LoadConfig(convoyCli.ConfigOverrides{Path: overrides.Path, Profile: args[0], Locked: true})
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			c.ActiveProfile = args[0]
+
+			err = c.WriteToDisk()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("Switched to profile %s (%s)\n", args[0], c.Host)
+		},
+	}
+}
+
+func addProfileRenameCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename <name> <new-name>",
+		Short: "Renames a profile",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			unlock := lockConfig()
+			defer unlock()
+
+			c, err := convoyCli.LoadConfig(convoyCli.ConfigOverrides{Path: overrides.Path, Locked: true})
+			if err != nil {
+				log.Fatal("Error loading config file:", err)
+			}
+
+			err = c.RenameProfile(args[0], args[1])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			err = c.WriteToDisk()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("Renamed profile %s to %s\n", args[0], args[1])
+		},
+	}
+}
+
+func addProfileDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Deletes a profile, the cli forgets its host, key and projects",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			unlock := lockConfig()
+			defer unlock()
+
+			c, err := convoyCli.LoadConfig(convoyCli.ConfigOverrides{Path: overrides.Path, Locked: true})
+			if err != nil {
+				log.Fatal("Error loading config file:", err)
+			}
+
+			err = c.DeleteProfile(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			err = c.WriteToDisk()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("Deleted profile %s\n", args[0])
+			if len(c.Profiles) > 0 {
+				fmt.Printf("The active profile is %s\n", c.ActiveProfile)
+			}
+		},
+	}
+}
+
+// lockConfig holds the config lock while a command changes the config.
+func lockConfig() func() {
+	unlock, err := convoyCli.LockConfig(overrides)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return unlock
+}
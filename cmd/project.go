@@ -1,18 +1,35 @@
 package main
 
 import (
-	"bytes"
+	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
-	convoyCli "github.com/frain-dev/convoy-cli"
-	log "github.com/sirupsen/logrus"
 	"os"
+	"strconv"
 	"strings"
 
+	convoyCli "github.com/frain-dev/convoy-cli"
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+
 	"github.com/frain-dev/convoy/util"
 	"github.com/spf13/cobra"
 )
 
+// projectItem is a project as `project list` prints it.
+type projectItem struct {
+	UID            string `json:"uid" yaml:"uid"`
+	Name           string `json:"name" yaml:"name"`
+	Type           string `json:"type" yaml:"type"`
+	OrganisationID string `json:"organisation_id,omitempty" yaml:"organisation_id,omitempty"`
+	Host           string `json:"host" yaml:"host"`
+	DeviceID       string `json:"device_id" yaml:"device_id"`
+	Active         bool   `json:"active" yaml:"active"`
+}
+
 func addProjectCommand() *cobra.Command {
 	var list bool
 	var refresh bool
@@ -20,44 +37,163 @@ func addProjectCommand() *cobra.Command {
 
 	cmd := &cobra.Command{
 		Use:   "project",
-		Short: "Switch, List or Refresh projects",
+		Short: "List, show, switch or refresh projects",
+		Run: func(cmd *cobra.Command, args []string) {
+			// the flags predate the subcommands, they still work for existing scripts
+			var err error
+			switch {
+			case list:
+				err = listProjects("table", "", "")
+			case refresh:
+				err = login("", "", false)
+			case !util.IsStringEmpty(projectId):
+				err = useProject(projectId)
+			default:
+				err = cmd.Help()
+			}
+
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&list, "list", false, "List all projects")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Refresh the project list")
+	cmd.Flags().StringVar(&projectId, "switch-to", "", "Switch to specified project")
+	_ = cmd.Flags().MarkDeprecated("list", "use `project list` instead")
+	_ = cmd.Flags().MarkDeprecated("refresh", "use `project refresh` instead")
+	_ = cmd.Flags().MarkDeprecated("switch-to", "use `project use` instead")
+
+	cmd.AddCommand(addProjectListCommand())
+	cmd.AddCommand(addProjectUseCommand())
+	cmd.AddCommand(addProjectRefreshCommand())
+	cmd.AddCommand(addProjectShowCommand())
+
+	return cmd
+}
+
+func addProjectListCommand() *cobra.Command {
+	var output string
+	var projectType string
+	var org string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists your projects, the active one is marked with *",
+		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			if list {
-				err := listProjects()
+			err := listProjects(output, projectType, org)
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "The output format, table, json or yaml")
+	cmd.Flags().StringVar(&projectType, "type", "", "Only list the projects of this type, incoming or outgoing")
+	cmd.Flags().StringVar(&org, "org", "", "Only list the projects of this organisation id, run `convoy-cli project refresh` first if your projects were listed before")
+
+	return cmd
+}
+
+func addProjectUseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use [id|name]",
+		Short: "Switches the active project, picked from a list when none is given",
+		Long: `Switches the active project of the profile in use. The project is found by
+its id or name, a part of the name is enough when only one project matches.
+Without an argument the projects are listed to pick one from.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			idOrName := ""
+			if len(args) > 0 {
+				idOrName = args[0]
+			}
+
+			err := useProject(idOrName)
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+}
+
+func addProjectRefreshCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "refresh",
+		Short: "Fetches your projects from the host again",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := login("", "", false)
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+}
+
+func addProjectShowCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "show [id|name]",
+		Short: "Shows the sources and endpoints of a project, the active one by default",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if output != "table" && output != "json" {
+				log.Fatalf("unknown output %q, use table or json", output)
+			}
+
+			c, client := deviceClient()
+
+			id := c.ActiveProjectID
+			if len(args) > 0 {
+				p, err := matchProject(c.Projects, args[0])
 				if err != nil {
 					log.Fatal(err)
 				}
-				return
+				id = p.UID
+			}
+
+			if util.IsStringEmpty(id) {
+				log.Fatal("You have no active project, run `convoy-cli project use` to choose one")
 			}
 
-			if refresh {
-				err := login("", "", false)
+			details, err := client.GetProject(id)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if output == "json" {
+				err = printJSON(details)
 				if err != nil {
 					log.Fatal(err)
 				}
 				return
 			}
 
-			err := switchProject(projectId)
-			if err != nil {
-				log.Fatal(err)
-			}
+			printProjectDetails(c.Host, details)
 		},
 	}
 
-	cmd.Flags().BoolVar(&list, "list", false, "List all projects")
-	cmd.Flags().BoolVar(&refresh, "refresh", false, "Refresh the project list")
-	cmd.Flags().StringVar(&projectId, "switch-to", "", "Switch to specified project")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "The output format, table or json")
 
 	return cmd
 }
 
-func switchProject(projectId string) error {
-	if util.IsStringEmpty(projectId) {
-		return errors.New("project id is required")
+func useProject(idOrName string) error {
+	// the active project written below wouldn't be used while it's overridden
+	source, err := convoyCli.ProjectOverride(overrides)
+	if err != nil {
+		return err
+	}
+
+	if !util.IsStringEmpty(source) {
+		return fmt.Errorf("the project is set by %s, which overrides the active project\nRemove it to switch projects with `convoy-cli project use`", source)
 	}
 
-	c, err := convoyCli.NewConfig("", "")
+	c, err := convoyCli.LoadConfig(overrides)
 	if err != nil {
 		return err
 	}
@@ -66,53 +202,196 @@ func switchProject(projectId string) error {
 		return errors.New("login with your personal access key to be able to use the switch command")
 	}
 
-	project := FindProjectById(c.Projects, projectId)
-	if project == nil {
-		return fmt.Errorf("project with id: %s not found\nRun `convoy-cli project --refresh` to refresh the project list", projectId)
+	if len(c.Projects) == 0 {
+		return errors.New("you have no projects\nRun `convoy-cli project refresh` to refresh the project list")
 	}
 
-	c.ActiveProjectID = project.UID
+	var project *convoyCli.ConfigProject
+	if util.IsStringEmpty(idOrName) {
+		project, err = pickProject(c)
+	} else {
+		project, err = matchProject(c.Projects, idOrName)
+	}
+	if err != nil {
+		return err
+	}
+
+	changeConfig(func(c *convoyCli.Config) error {
+		c.ActiveProjectID = project.UID
+		return nil
+	})
 
-	err = c.WriteToDisk()
+	c, err = convoyCli.LoadConfig(overrides)
 	if err != nil {
 		return err
 	}
 
+	if c.ActiveProjectID != project.UID {
+		return fmt.Errorf("failed to switch to %s, the active project is still %s", project.Name, c.ActiveProjectID)
+	}
+
 	fmt.Printf("Successfully switched to %s\n", project.Name)
 	return nil
 }
 
-func listProjects() error {
-	c, err := convoyCli.NewConfig("", "")
+// matchProject returns the one project an id or name refers to.
+func matchProject(projects []convoyCli.ConfigProject, idOrName string) (*convoyCli.ConfigProject, error) {
+	matches := convoyCli.MatchProjects(projects, idOrName)
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("project %s not found\nRun `convoy-cli project refresh` to refresh the project list", idOrName)
+	case 1:
+		return &matches[0], nil
+	}
+
+	candidates := make([]string, 0, len(matches))
+	for _, p := range matches {
+		candidates = append(candidates, fmt.Sprintf("%s (%s)", p.Name, p.UID))
+	}
+	return nil, fmt.Errorf("%s matches %d projects, use one of their ids:\n  %s", idOrName, len(matches), strings.Join(candidates, "\n  "))
+}
+
+// pickProject lists the projects and asks which one to use, by number or
+// by id or name.
+func pickProject(c *convoyCli.Config) (*convoyCli.ConfigProject, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, errors.New("project id or name is required")
+	}
+
+	current := ""
+	for i, p := range c.Projects {
+		mark := " "
+		if p.UID == c.ActiveProjectID {
+			mark = "*"
+			current = strconv.Itoa(i + 1)
+		}
+		fmt.Printf("%s %2d) %s (%s, %s)\n", mark, i+1, p.Name, p.UID, p.Type)
+	}
+
+	r := bufio.NewReader(os.Stdin)
+	for {
+		answer := prompt(r, "Project", current)
+		if util.IsStringEmpty(answer) {
+			return nil, errors.New("no project picked")
+		}
+
+		if n, err := strconv.Atoi(answer); err == nil {
+			if n >= 1 && n <= len(c.Projects) {
+				return &c.Projects[n-1], nil
+			}
+			fmt.Printf("Pick a number from 1 to %d\n", len(c.Projects))
+			continue
+		}
+
+		p, err := matchProject(c.Projects, answer)
+		if err == nil {
+			return p, nil
+		}
+		fmt.Println(err)
+	}
+}
+
+func listProjects(output, projectType, org string) error {
+	if output != "table" && output != "json" && output != "yaml" {
+		return fmt.Errorf("unknown output %q, use table, json or yaml", output)
+	}
+
+	c, err := convoyCli.LoadConfig(overrides)
 	if err != nil {
 		return err
 	}
 
-	buf := bytes.Buffer{}
+	items := []projectItem{}
 	for _, p := range c.Projects {
-		status := "Inactive"
-		if c.ActiveProjectID == p.UID {
-			status = "Active"
+		if !util.IsStringEmpty(projectType) && !strings.EqualFold(p.Type, projectType) {
+			continue
 		}
+		if !util.IsStringEmpty(org) && p.OrganisationID != org {
+			continue
+		}
+
+		items = append(items, projectItem{
+			UID:            p.UID,
+			Name:           p.Name,
+			Type:           p.Type,
+			OrganisationID: p.OrganisationID,
+			Host:           p.Host,
+			DeviceID:       p.DeviceID,
+			Active:         p.UID == c.ActiveProjectID,
+		})
+	}
 
-		v := "- ID: %s\n Name: %s\n Type: %s\n Host: %s\n Status: %s\n\n"
-		formated := fmt.Sprintf(v, p.UID, p.Name, p.Type, p.Host, status)
+	switch output {
+	case "json":
+		return printJSON(items)
+	case "yaml":
+		return yaml.NewEncoder(os.Stdout).Encode(items)
+	}
 
-		buf.WriteString(formated)
+	if len(items) == 0 {
+		fmt.Println("No projects found, run `convoy-cli project refresh` to refresh the project list")
+		return nil
 	}
 
-	_, err = buf.WriteTo(os.Stdout)
-	return err
+	t := newTable("", "ID", "NAME", "TYPE", "ORGANISATION")
+	for _, p := range items {
+		mark := ""
+		if p.Active {
+			mark = "*"
+		}
+		t.AppendRow(table.Row{mark, p.UID, p.Name, p.Type, p.OrganisationID})
+	}
+	t.Render()
+
+	return nil
 }
 
-func FindProjectById(projects []convoyCli.ConfigProject, projectId string) *convoyCli.ConfigProject {
-	var project *convoyCli.ConfigProject
+func printProjectDetails(host string, details *convoyCli.ProjectDetails) {
+	p := details.Project
+	fmt.Printf("%-14s %s (%s)\n", "Project:", p.Name, p.UID)
+	fmt.Printf("%-14s %s\n", "Type:", p.Type)
+	if !util.IsStringEmpty(p.OrganisationID) {
+		fmt.Printf("%-14s %s\n", "Organisation:", p.OrganisationID)
+	}
+
+	fmt.Printf("\nSources (%d)\n", len(details.Sources))
+	if len(details.Sources) > 0 {
+		t := newTable("ID", "NAME", "TYPE", "PROVIDER", "URL", "STATUS")
+		for _, s := range details.Sources {
+			url := ""
+			if !util.IsStringEmpty(s.MaskID) {
+				url = strings.TrimSuffix(host, "/") + "/ingest/" + s.MaskID
+			}
 
-	for _, endpoint := range projects {
-		if strings.TrimSpace(strings.ToLower(endpoint.UID)) == strings.TrimSpace(strings.ToLower(projectId)) {
-			return &endpoint
+			status := "enabled"
+			if s.IsDisabled {
+				status = "disabled"
+			}
+			t.AppendRow(table.Row{s.UID, s.Name, s.Type, s.Provider, url, status})
+		}
+		t.Render()
+	}
+
+	fmt.Printf("\nEndpoints (%d)\n", len(details.Endpoints))
+	if len(details.Endpoints) > 0 {
+		t := newTable("ID", "TITLE", "TARGET URL")
+		for _, e := range details.Endpoints {
+			t.AppendRow(table.Row{e.UID, e.Title, e.TargetURL})
 		}
+		t.Render()
 	}
+}
+
+func newTable(header ...interface{}) table.Writer {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(header)
+	return t
+}
 
-	return project
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
 }
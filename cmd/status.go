@@ -1,7 +1,11 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+
 	convoyCli "github.com/frain-dev/convoy-cli"
 	"github.com/frain-dev/convoy-cli/util"
 	log "github.com/sirupsen/logrus"
@@ -9,36 +13,114 @@ import (
 )
 
 func addStatusCommand() *cobra.Command {
+	var output string
+	var offline bool
+
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Checks status of the cli login",
+		Long: `Verifies the api key of the profile in use with its host and shows the
+user it belongs to, the active project, this machine's device in it and
+whether a background listener is running. It exits with status 1 when you
+are not logged in or the host rejects the api key.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			c, err := convoyCli.LoadConfig()
-			if err != nil {
-				log.Fatal("Error loading config file:", err)
+			if output != "text" && output != "json" {
+				log.Fatalf("unknown output %q, use text or json", output)
 			}
 
-			hasAPIKey := !util.IsStringEmpty(c.ActiveApiKey)
-			hasProjects := len(c.Projects) > 0
-
-			if hasProjects && hasAPIKey {
-				fmt.Printf("You are logged in with %d Projects\n", len(c.Projects))
-				p := FindProjectById(c.Projects, c.ActiveProjectID)
+			// the status reports a failed verification, the request's logs only add noise
+			log.SetLevel(log.WarnLevel)
 
-				if p == nil {
-					fmt.Println("You have no active project\nRun `convoy-cli project --list` to list your projects")
-					fmt.Println("Then run `convoy-cli project --switch-to {project_id}` to choose a project")
-				} else {
-					fmt.Printf("Your current active project is %s\n", p.Name)
-				}
+			s := &convoyCli.LoginStatus{Profile: overrides.Profile}
+			c, err := convoyCli.LoadConfig(overrides)
+			switch {
+			case err == nil:
+				s = c.CheckLogin(offline)
+			case !errors.Is(err, convoyCli.ErrConfigNotFound):
+				log.Fatal("Error loading config file:", err)
+			}
 
-				return
+			s.Listener, err = sendDaemonCommand(convoyCli.ControlStatus)
+			if err != nil && !errors.Is(err, convoyCli.ErrDaemonNotRunning) {
+				log.WithError(err).Warn("failed to get the status of the background listener")
 			}
 
-			fmt.Println("You are not logged in, run `convoy-cli login --api-key {api-key} --host {host}` to login")
+			if output == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err = enc.Encode(s); err != nil {
+					log.Fatal(err)
+				}
+			} else {
+				printLoginStatus(s)
+			}
 
+			if !s.LoggedIn {
+				os.Exit(1)
+			}
 		},
 	}
 
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "The output format, text or json")
+	cmd.Flags().BoolVar(&offline, "offline", false, "Show the status of the config without verifying the api key with the host")
+
 	return cmd
 }
+
+func printLoginStatus(s *convoyCli.LoginStatus) {
+	switch {
+	case s.LoggedIn && s.Verified:
+		if util.IsStringEmpty(s.UserName) {
+			fmt.Println("You are logged in")
+		} else {
+			fmt.Printf("You are logged in as %s\n", s.UserName)
+		}
+	case s.LoggedIn && !util.IsStringEmpty(s.Error):
+		fmt.Printf("You are logged in, but the api key couldn't be verified: %s\n", s.Error)
+	case s.LoggedIn:
+		fmt.Println("You are logged in, the api key wasn't verified")
+	case !util.IsStringEmpty(s.Error):
+		fmt.Printf("The host rejected your api key, it has expired or was revoked: %s\n", s.Error)
+		fmt.Println("Run `convoy-cli login` to login again")
+	default:
+		fmt.Println("You are not logged in, run `convoy-cli login --api-key {api-key} --host {host}` to login")
+	}
+
+	if !util.IsStringEmpty(s.Profile) {
+		fmt.Printf("%-10s %s\n", "Profile:", s.Profile)
+	}
+	if !util.IsStringEmpty(s.Host) {
+		fmt.Printf("%-10s %s\n", "Host:", s.Host)
+	}
+
+	if s.LoggedIn {
+		fmt.Printf("%-10s %d\n", "Projects:", s.Projects)
+
+		if p := s.Project; p != nil {
+			project := fmt.Sprintf("%s (%s)", p.Name, p.UID)
+			if !util.IsStringEmpty(p.Type) {
+				project = fmt.Sprintf("%s (%s, %s)", p.Name, p.UID, p.Type)
+			}
+			fmt.Printf("%-10s %s\n", "Project:", project)
+
+			if !util.IsStringEmpty(p.DeviceID) {
+				device := p.DeviceID
+				if !util.IsStringEmpty(p.DeviceStatus) {
+					device += ", " + p.DeviceStatus
+				}
+				if p.LastSeenAt != nil {
+					device += ", last seen " + lastSeen(*p.LastSeenAt)
+				}
+				fmt.Printf("%-10s %s\n", "Device:", device)
+			}
+		} else {
+			fmt.Println("You have no active project, run `convoy-cli project use` to choose one")
+		}
+	}
+
+	if s.Listener == nil {
+		fmt.Printf("%-10s %s\n", "Listener:", "not running")
+	} else {
+		printListenerStatus(s.Listener)
+	}
+}
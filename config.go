@@ -1,69 +1,167 @@
 package convoy_cli
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/frain-dev/convoy-cli/util"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const (
 	defaultConfigDir = ".convoy/config"
+
+	// backupSuffix names the copy of the config file kept on each write
+	backupSuffix = ".bak"
+
+	DefaultProfileName = "default"
 )
 
+// ErrConfigNotFound is returned by LoadConfig when there's no config file and
+// no host and api key are given.
+var ErrConfigNotFound = errors.New("config file not found")
+
+// Config holds the profiles of every Convoy instance the cli is logged into.
+// The fields of the profile in use are promoted, so c.Host is the host of
+// the profile selected when the config was loaded.
 type Config struct {
-	Host                 string          `yaml:"host"`
-	ActiveApiKey         string          `yaml:"active_api_key"`
-	ActiveProjectID      string          `yaml:"active_project_id"`
-	Projects             []ConfigProject `yaml:"projects"`
+	*Profile `yaml:"-"`
+
+	Version int `yaml:"version"`
+
+	ActiveProfile string              `yaml:"active_profile"`
+	Profiles      map[string]*Profile `yaml:"profiles"`
+
+	// SecretStore is where new api keys are stored, keyring or file. When
+	// empty the OS keyring is used, falling back to the encrypted file.
+	SecretStore string `yaml:"secret_store,omitempty"`
+
+	// Listen are the defaults of listen, shared with config export
+	Listen *ListenDefaults `yaml:"listen,omitempty"`
+
+	// MachineID identifies this machine in the host name sent to every
+	// host, it is generated on the first login and doesn't change with the
+	// network. The hosts issue the device id of each project.
+	MachineID string `yaml:"machine_id,omitempty"`
+
 	path                 string
+	profileName          string
+	overrides            ConfigOverrides
 	hasDefaultConfigFile bool
-	isNewHost            bool
+	stores               map[string]SecretStore
+
+	// locked is set when the caller holds the config lock
+	locked bool
+}
+
+// Profile is the login to one Convoy instance. Its api key is kept in a
+// secret store, the config file only has a reference to it.
+type Profile struct {
+	Host            string          `yaml:"host"`
+	ActiveApiKey    string          `yaml:"active_api_key,omitempty"`
+	ApiKeyRef       string          `yaml:"api_key_ref,omitempty"`
+	ActiveProjectID string          `yaml:"active_project_id"`
+	UserName        string          `yaml:"user_name,omitempty"`
+	Projects        []ConfigProject `yaml:"projects"`
+
+	// the api key as it is in the secret store
+	storedApiKey string
 }
 
 type ConfigProject struct {
-	UID  string `yaml:"uid"`
-	Name string `yaml:"name"`
-	Host string `yaml:"host"`
-	Type string `yaml:"type"`
+	UID            string `yaml:"uid"`
+	Name           string `yaml:"name"`
+	Host           string `yaml:"host"`
+	Type           string `yaml:"type"`
+	OrganisationID string `yaml:"organisation_id,omitempty"`
 	//ApiKey   string `yaml:"api_key"`
 	DeviceID string `yaml:"device_id"`
 }
 
-func DeleteConfigFile() error {
-	homedir, err := os.UserHomeDir()
+// DeleteConfigFile deletes the config file and the api keys of its profiles.
+func DeleteConfigFile(o ConfigOverrides) error {
+	o, err := o.resolve()
 	if err != nil {
 		return err
 	}
 
-	path := filepath.Join(homedir, defaultConfigDir)
+	c := &Config{path: o.Path, locked: o.Locked}
+	if err = c.load(); err == nil {
+		for _, name := range c.ProfileNames() {
+			c.deleteApiKey(name, c.Profiles[name])
+		}
+	}
 
-	return os.Remove(path)
+	return os.Remove(o.Path)
 }
 
-func LoadConfig() (*Config, error) {
-	homedir, err := os.UserHomeDir()
+// LoadConfig loads the config file with the profile in use and the values
+// overridden by flags, the environment or the project file applied. The
+// active profile is used when none is given. Without a config file, a host
+// and api key must be given. Config files written before profiles existed
+// are migrated to a default profile.
+func LoadConfig(o ConfigOverrides) (*Config, error) {
+	o, err := o.resolve()
 	if err != nil {
 		return nil, err
 	}
 
-	path := filepath.Join(homedir, defaultConfigDir)
-
-	c := &Config{path: path}
-	c.hasDefaultConfigFile = HasDefaultConfigFile(path)
+	c := &Config{path: o.Path, Profiles: map[string]*Profile{}, Profile: &Profile{}, locked: o.Locked}
+	c.hasDefaultConfigFile = HasDefaultConfigFile(o.Path)
 
 	if !c.hasDefaultConfigFile {
-		return nil, errors.New("config file not found")
+		if util.IsStringEmpty(o.Host) || util.IsStringEmpty(o.ApiKey) {
+			return nil, fmt.Errorf("%w: %s, run `convoy-cli login` or set %s and %s", ErrConfigNotFound, o.Path, HostEnv, ApiKeyEnv)
+		}
+
+		c.applyOverrides(o)
+		return c, nil
 	}
 
-	data, err := os.ReadFile(path)
+	err = c.load()
 	if err != nil {
 		return nil, err
 	}
 
-	err = yaml.Unmarshal(data, &c)
+	profile := o.Profile
+	if util.IsStringEmpty(profile) {
+		profile = c.ActiveProfile
+	}
+
+	// without an active profile, e.g. after it was deleted, the cli isn't logged in
+	if _, ok := c.Profiles[profile]; ok || !util.IsStringEmpty(o.Profile) {
+		err = c.UseProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.applyOverrides(o)
+	return c, nil
+}
+
+// ReadConfig loads the config file without using any profile, so no api
+// key is read from a secret store, e.g. to list the profiles.
+func ReadConfig(o ConfigOverrides) (*Config, error) {
+	o, err := o.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{path: o.Path, Profiles: map[string]*Profile{}, Profile: &Profile{}, locked: o.Locked}
+	c.hasDefaultConfigFile = HasDefaultConfigFile(o.Path)
+
+	if !c.hasDefaultConfigFile {
+		return nil, fmt.Errorf("%w: %s, run `convoy-cli login`", ErrConfigNotFound, o.Path)
+	}
+
+	err = c.load()
 	if err != nil {
 		return nil, err
 	}
@@ -71,138 +169,584 @@ func LoadConfig() (*Config, error) {
 	return c, nil
 }
 
-func NewConfig(host, apiKey string) (*Config, error) {
-	homedir, err := os.UserHomeDir()
+// NewConfig loads the config file for logging into a host with an api key,
+// both are resolved like in LoadConfig but written to the profile. The
+// named profile is used, and created when it doesn't exist. Without a name,
+// the profile already logged into the host is used, or the active profile
+// when the host is empty or the same. Logging into another host creates a
+// profile named after it instead of overwriting the active one.
+func NewConfig(o ConfigOverrides) (*Config, error) {
+	o, err := o.resolve()
 	if err != nil {
 		return nil, err
 	}
 
-	path := filepath.Join(homedir, defaultConfigDir)
+	profile, host, apiKey := o.Profile, o.Host, o.ApiKey
 
-	c := &Config{path: path}
-	c.hasDefaultConfigFile = HasDefaultConfigFile(path)
+	c := &Config{path: o.Path, Profiles: map[string]*Profile{}, locked: o.Locked}
+	c.hasDefaultConfigFile = HasDefaultConfigFile(o.Path)
 
 	if c.hasDefaultConfigFile {
-		data, err := os.ReadFile(path)
+		err = c.load()
 		if err != nil {
 			return nil, err
 		}
+	} else {
+		err = os.MkdirAll(filepath.Dir(o.Path), 0700)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create config directory: %v", err)
+		}
+	}
 
-		err = yaml.Unmarshal(data, &c)
+	if util.IsStringEmpty(profile) {
+		profile = c.profileForHost(host)
+	}
+
+	p, ok := c.Profiles[profile]
+	if !ok {
+		p = &Profile{}
+		c.Profiles[profile] = p
+	}
+	c.Profile = p
+	c.profileName = profile
+
+	if util.IsStringEmpty(apiKey) {
+		err = c.loadApiKey(p)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		if !util.IsStringEmpty(host) {
-			c.isNewHost = IsNewHost(c.Host, host)
-			c.Host = host
+	if !util.IsStringEmpty(host) {
+		if !util.IsStringEmpty(p.Host) && IsNewHost(p.Host, host) {
+			// the projects and devices belong to the previous host
+			p.ActiveProjectID = ""
+			p.Projects = nil
 		}
+		p.Host = host
+	}
+
+	if !util.IsStringEmpty(apiKey) {
+		p.ActiveApiKey = apiKey
+	}
 
-		if !util.IsStringEmpty(apiKey) {
-			c.ActiveApiKey = apiKey
+	return c, nil
+}
+
+// load reads the config file, older versions are upgraded to ConfigVersion.
+func (c *Config) load() error {
+	err := tightenPermissions(c.path, c.isDefaultPath())
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return c.corrupt(errors.New("the file is empty"))
+	}
+
+	m, err := c.planMigration(data)
+	if err != nil {
+		return err
+	}
+
+	if m != nil {
+		data = m.migrated
+	}
+
+	err = yaml.Unmarshal(data, c)
+	if err != nil {
+		return c.corrupt(err)
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = map[string]*Profile{}
+	}
+
+	if m == nil {
+		return nil
+	}
+
+	// the file of each version is kept, a later write doesn't replace it
+	if !HasDefaultConfigFile(m.Backup()) {
+		backup, err := withoutApiKeys(m.original)
+		if err != nil {
+			return err
 		}
-		return c, nil
-	} else {
-		err = os.Mkdir(homedir+"/.convoy", 0777)
-		if err != nil && !os.IsExist(err) {
-			return nil, fmt.Errorf("failed to create config directory: %v", err)
+
+		err = writeFileAtomic(m.Backup(), backup, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to back up config: %v", err)
 		}
 	}
 
-	c.Host = host
-	c.ActiveApiKey = apiKey
+	err = c.WriteToDisk()
+	if err != nil {
+		return fmt.Errorf("failed to migrate config: %v", err)
+	}
 
-	return c, nil
+	log.Printf("migrated config %s from version %d to %d, the previous file is kept at %s without its api keys", c.path, m.From, m.To, m.Backup())
+	if len(m.ApiKeys) > 0 {
+		log.Println("moved the api keys in the config file to the secret store")
+	}
+	return nil
 }
 
+// tightenPermissions makes the config file, and the directory of the default
+// one, private to the user. Older versions created them readable by everyone.
+func tightenPermissions(path string, dir bool) error {
+	modes := map[string]os.FileMode{path: 0600}
+	if dir {
+		modes[filepath.Dir(path)] = 0700
+	}
+
+	for p, mode := range modes {
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().Perm()&^mode != 0 {
+			if err = os.Chmod(p, mode); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// profileForHost picks the profile NewConfig logs into when none is named.
+func (c *Config) profileForHost(host string) string {
+	active, ok := c.Profiles[c.ActiveProfile]
+	if util.IsStringEmpty(host) {
+		if ok {
+			return c.ActiveProfile
+		}
+		return DefaultProfileName
+	}
+
+	for _, name := range c.ProfileNames() {
+		if c.Profiles[name].Host == host {
+			return name
+		}
+	}
+
+	if !ok || util.IsStringEmpty(active.Host) {
+		if util.IsStringEmpty(c.ActiveProfile) {
+			return DefaultProfileName
+		}
+		return c.ActiveProfile
+	}
+
+	name := host
+	if u, err := url.Parse(host); err == nil && !util.IsStringEmpty(u.Host) {
+		name = u.Host
+	}
+
+	unique := name
+	for i := 2; c.Profiles[unique] != nil; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	return unique
+}
+
+// corrupt returns the error for a config file that can't be parsed, with
+// the ways to recover from it.
+func (c *Config) corrupt(err error) error {
+	backup := c.path + backupSuffix
+	if HasDefaultConfigFile(backup) {
+		return fmt.Errorf("config file %s is corrupt: %v\nRestore the previous config with `cp %s %s`, or delete it and run `convoy-cli login`", c.path, err, backup, c.path)
+	}
+	return fmt.Errorf("config file %s is corrupt: %v\nDelete it and run `convoy-cli login`", c.path, err)
+}
+
+// WriteToDisk stores new api keys in the secret store, then replaces the
+// config file with one referring to them. The previous file is kept as a
+// backup next to it. It takes the config lock unless the caller holds it.
 func (c *Config) WriteToDisk() error {
-	d, err := yaml.Marshal(&c)
+	if !c.locked {
+		unlock, err := lockFile(c.path)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	c.writeBackProfile()
+
+	for _, name := range c.ProfileNames() {
+		p := c.Profiles[name]
+		if p.ActiveApiKey == p.storedApiKey {
+			continue
+		}
+
+		err := c.storeApiKey(p)
+		if err != nil {
+			return fmt.Errorf("failed to store the api key of profile %s: %v", name, err)
+		}
+	}
+
+	d, err := c.marshal()
 	if err != nil {
 		return err
 	}
 
-	if err = os.WriteFile(c.path, d, 0644); err != nil {
+	previous, err := os.ReadFile(c.path)
+	if err == nil && len(bytes.TrimSpace(previous)) > 0 && !bytes.Equal(previous, d) {
+		if err = writeFileAtomic(c.path+backupSuffix, previous, 0600); err != nil {
+			return fmt.Errorf("failed to back up config: %v", err)
+		}
+	}
+
+	if err = writeFileAtomic(c.path, d, 0600); err != nil {
 		return fmt.Errorf("failed to write config to disk: %v", err)
 	}
 
 	return nil
 }
 
+// marshal returns the config file, without the api keys.
+func (c *Config) marshal() ([]byte, error) {
+	out := Config{Version: ConfigVersion, ActiveProfile: c.ActiveProfile, SecretStore: c.SecretStore, Listen: c.Listen, MachineID: c.MachineID, Profiles: map[string]*Profile{}}
+	for name, p := range c.Profiles {
+		profile := *p
+		profile.ActiveApiKey = ""
+		out.Profiles[name] = &profile
+	}
+
+	return yaml.Marshal(&out)
+}
+
+func (c *Config) Path() string {
+	return c.path
+}
+
+func (c *Config) isDefaultPath() bool {
+	homedir, err := os.UserHomeDir()
+	return err == nil && c.path == filepath.Join(homedir, defaultConfigDir)
+}
+
+// ProfileName is the name of the profile in use.
+func (c *Config) ProfileName() string {
+	return c.profileName
+}
+
+// ProfileNames returns the names of all profiles, sorted.
+func (c *Config) ProfileNames() []string {
+	return sortedKeys(c.Profiles)
+}
+
+// UseProfile switches the profile in use, it doesn't change the active profile.
+func (c *Config) UseProfile(name string) error {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q not found\nRun `convoy-cli profile list` to list your profiles", name)
+	}
+
+	err := c.loadApiKey(p)
+	if err != nil {
+		return fmt.Errorf("failed to load the api key of profile %s: %v", name, err)
+	}
+
+	c.Profile = p
+	c.profileName = name
+	return nil
+}
+
+func (c *Config) RenameProfile(name, newName string) error {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	if util.IsStringEmpty(newName) {
+		return errors.New("profile name cannot be empty")
+	}
+
+	if _, ok = c.Profiles[newName]; ok {
+		return fmt.Errorf("profile %q already exists", newName)
+	}
+
+	delete(c.Profiles, name)
+	c.Profiles[newName] = p
+
+	if c.ActiveProfile == name {
+		c.ActiveProfile = newName
+	}
+
+	if c.profileName == name {
+		c.profileName = newName
+	}
+
+	return nil
+}
+
+// DeleteProfile removes the named profile. When it was the active profile,
+// the first remaining profile becomes active.
+func (c *Config) DeleteProfile(name string) error {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	c.deleteApiKey(name, p)
+	delete(c.Profiles, name)
+
+	if c.ActiveProfile == name {
+		c.ActiveProfile = ""
+		if names := c.ProfileNames(); len(names) > 0 {
+			c.ActiveProfile = names[0]
+		}
+	}
+
+	return nil
+}
+
+// EnsureMachineID returns the machine id, generating it when the config
+// doesn't have one yet. It is written with the config.
+func (c *Config) EnsureMachineID() string {
+	if util.IsStringEmpty(c.MachineID) {
+		c.MachineID = uuid.NewString()
+	}
+	return c.MachineID
+}
+
+// FindProject returns the project of the profile in use with the id, or
+// else the name, nil if there is none.
+func (c *Config) FindProject(idOrName string) *ConfigProject {
+	idOrName = strings.TrimSpace(idOrName)
+	for i := range c.Projects {
+		if strings.EqualFold(strings.TrimSpace(c.Projects[i].UID), idOrName) {
+			return &c.Projects[i]
+		}
+	}
+
+	for i := range c.Projects {
+		if strings.EqualFold(strings.TrimSpace(c.Projects[i].Name), idOrName) {
+			return &c.Projects[i]
+		}
+	}
+	return nil
+}
+
+// ProfileForHost returns the profile logged into host.
+func (c *Config) ProfileForHost(host string) (string, error) {
+	for _, name := range c.ProfileNames() {
+		if !IsNewHost(c.Profiles[name].Host, host) {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("no profile is logged into %s\nRun `convoy-cli profile list` to list your profiles", host)
+}
+
+// SelectProfile returns the profile a command on one profile applies to:
+// the one named by the overrides, else the one logged into their host, both
+// resolved from the flags, environment and session config like in
+// LoadConfig, else the active profile.
+func (c *Config) SelectProfile(o ConfigOverrides) (string, error) {
+	o, err := o.resolve()
+	if err != nil {
+		return "", err
+	}
+
+	name := o.Profile
+	if util.IsStringEmpty(name) && !util.IsStringEmpty(o.Host) {
+		name, err = c.ProfileForHost(o.Host)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if util.IsStringEmpty(name) {
+		name = c.ActiveProfile
+	}
+
+	if util.IsStringEmpty(name) {
+		return "", errors.New("you are not logged in")
+	}
+
+	return name, nil
+}
+
 func (c *Config) HasDefaultConfigFile() bool {
 	return c.hasDefaultConfigFile
 }
 
+// UpdateConfig replaces the projects of the profile in use with the ones in
+// the login response. On login the profile also becomes the active profile.
 func (c *Config) UpdateConfig(response *LoginResponse, isLogin bool) error {
 	if len(response.Projects) > 0 && isLogin {
 		c.ActiveProjectID = response.Projects[0].Project.UID
 	}
 
-	c.Projects = make([]ConfigProject, 0, len(response.Projects))
+	if isLogin {
+		c.ActiveProfile = c.profileName
+	}
 
-	for i := range response.Projects {
-		rp := &response.Projects[i]
+	c.SetProjects(response)
 
-		c.Projects = append(c.Projects, ConfigProject{
-			UID:      rp.Project.UID,
-			Name:     rp.Project.Name,
-			Host:     c.Host,
-			Type:     rp.Project.Type,
-			DeviceID: rp.Device.UID,
-		})
+	err := c.WriteToDisk()
+	if err != nil {
+		return err
 	}
 
-	//if c.hasDefaultConfigFile {
-	//	if c.isNewHost {
-	//		// if the host is different from the current host in the config file,
-	//		// the data in the config file is overwritten
-	//		c.Projects = []ConfigProject{
-	//			{
-	//				UID:      response.Endpoint.UID,
-	//				Name:     name,
-	//				ApiKey:   c.ActiveApiKey,
-	//				DeviceID: response.Device.UID,
-	//			},
-	//		}
-	//	}
-	//
-	//	if c.isNewApiKey {
-	//		if doesEndpointExist(c, response.Endpoint.UID) {
-	//			return fmt.Errorf("endpoint with ID (%s) has been added already", response.Endpoint.UID)
-	//		}
-	//
-	//		// If the api key provided is different from the active api key,
-	//		// we append the project returned to the list of projects within the config
-	//		c.Projects = append(c.Projects, ConfigProject{
-	//			UID:      response.Endpoint.UID,
-	//			Name:     name,
-	//			ApiKey:   c.ActiveApiKey,
-	//			DeviceID: response.Device.UID,
-	//		})
-	//	}
-	//
-	//} else {
-	//	// Make sure the directory holding our config exists
-	//	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
-	//		return err
-	//	}
-	//	c.Projects = []ConfigProject{
-	//		{
-	//			UID:      response.Endpoint.UID,
-	//			Name:     name,
-	//			ApiKey:   c.ActiveApiKey,
-	//			DeviceID: response.Device.UID,
-	//		},
-	//	}
-	//}
+	return nil
+}
 
-	err := c.WriteToDisk()
+// store returns the named secret store, it is opened once per config so the
+// passphrase of the encrypted file is only asked for once.
+func (c *Config) store(name string) (SecretStore, error) {
+	if s, ok := c.stores[name]; ok {
+		return s, nil
+	}
+
+	s, err := newSecretStore(name, filepath.Dir(c.path))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.stores == nil {
+		c.stores = map[string]SecretStore{}
+	}
+	c.stores[name] = s
+	return s, nil
+}
+
+// loadApiKey reads the api key of p from the secret store.
+func (c *Config) loadApiKey(p *Profile) error {
+	if !util.IsStringEmpty(p.ActiveApiKey) || util.IsStringEmpty(p.ApiKeyRef) {
+		return nil
+	}
+
+	name, id, err := parseSecretRef(p.ApiKeyRef)
+	if err != nil {
+		return err
+	}
+
+	s, err := c.store(name)
+	if err != nil {
+		return err
+	}
+
+	p.ActiveApiKey, err = s.Get(id)
 	if err != nil {
 		return err
 	}
 
+	p.storedApiKey = p.ActiveApiKey
 	return nil
 }
 
+// storeApiKey writes the api key of p to the secret store it refers to, or
+// to the configured store when it has no reference yet.
+func (c *Config) storeApiKey(p *Profile) error {
+	if !util.IsStringEmpty(p.ApiKeyRef) {
+		name, id, err := parseSecretRef(p.ApiKeyRef)
+		if err != nil {
+			return err
+		}
+
+		if util.IsStringEmpty(c.SecretStore) || name == c.SecretStore {
+			s, err := c.store(name)
+			if err != nil {
+				return err
+			}
+
+			err = s.Set(id, p.ActiveApiKey)
+			if err != nil {
+				return err
+			}
+
+			p.storedApiKey = p.ActiveApiKey
+			return nil
+		}
+
+		// the key moves to the configured store
+		if s, err := c.store(name); err == nil {
+			_ = s.Delete(id)
+		}
+	}
+
+	id := uuid.NewString()
+	names := []string{c.SecretStore}
+	if util.IsStringEmpty(c.SecretStore) {
+		names = []string{SecretStoreKeyring, SecretStoreFile}
+	}
+
+	var err error
+	for _, name := range names {
+		var s SecretStore
+		s, err = c.store(name)
+		if err != nil {
+			return err
+		}
+
+		err = s.Set(id, p.ActiveApiKey)
+		if err != nil {
+			if name == SecretStoreKeyring && len(names) > 1 {
+				log.WithError(err).Warnln("the OS keyring isn't available, using the encrypted credentials file")
+			}
+			continue
+		}
+
+		p.ApiKeyRef = secretRef(name, id)
+		p.storedApiKey = p.ActiveApiKey
+		return nil
+	}
+
+	return err
+}
+
+// deleteApiKey removes the api key of a profile from its secret store, a
+// key that can't be deleted is only logged.
+func (c *Config) deleteApiKey(profile string, p *Profile) {
+	if util.IsStringEmpty(p.ApiKeyRef) {
+		return
+	}
+
+	name, id, err := parseSecretRef(p.ApiKeyRef)
+	if err == nil {
+		var s SecretStore
+		s, err = c.store(name)
+		if err == nil {
+			err = s.Delete(id)
+		}
+	}
+
+	if err != nil && !errors.Is(err, ErrSecretNotFound) {
+		log.WithError(err).Warnf("failed to delete the api key of profile %s", profile)
+	}
+}
+
+// SetProjects replaces the projects and the user name of the profile in use
+// with the ones in the login response, without writing them to disk.
+func (c *Config) SetProjects(response *LoginResponse) {
+	if !util.IsStringEmpty(response.UserName) {
+		c.UserName = response.UserName
+	}
+
+	c.Projects = make([]ConfigProject, 0, len(response.Projects))
+
+	for i := range response.Projects {
+		rp := &response.Projects[i]
+
+		c.Projects = append(c.Projects, ConfigProject{
+			UID:            rp.Project.UID,
+			Name:           rp.Project.Name,
+			Host:           c.Host,
+			Type:           rp.Project.Type,
+			OrganisationID: rp.Project.OrganisationID,
+			DeviceID:       rp.Device.UID,
+		})
+	}
+}
+
 func HasDefaultConfigFile(path string) bool {
 	if _, err := os.Stat(path); err != nil {
 		return false
@@ -0,0 +1,299 @@
+package convoy_cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func writeConfigFile(t *testing.T, data string) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	keyring.MockInit()
+
+	path := filepath.Join(home, defaultConfigDir)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+
+	return path
+}
+
+func TestLoadConfig_MigratesSingleHost(t *testing.T) {
+	path := writeConfigFile(t, `
+host: https://cli.getconvoy.io
+active_api_key: key
+active_project_id: p1
+projects:
+  - uid: p1
+    name: payments
+    device_id: d1
+`)
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.Equal(t, DefaultProfileName, c.ProfileName())
+	require.Equal(t, DefaultProfileName, c.ActiveProfile)
+	require.Equal(t, "https://cli.getconvoy.io", c.Host)
+	require.Equal(t, "key", c.ActiveApiKey)
+	require.Equal(t, "p1", c.ActiveProjectID)
+	require.Equal(t, []ConfigProject{{UID: "p1", Name: "payments", DeviceID: "d1"}}, c.Projects)
+
+	// the migrated config is written back without the top level host and
+	// with a private reference to the key
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "active_profile: default")
+	require.Contains(t, string(data), "api_key_ref: keyring:")
+	require.NotContains(t, string(data), "\nhost:")
+	require.NotContains(t, string(data), "key\n")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	c, err = LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.Equal(t, "key", c.ActiveApiKey)
+
+	_, err = LoadConfig(ConfigOverrides{Profile: "staging"})
+	require.Error(t, err)
+}
+
+func TestReadConfig_DoesNotLoadApiKeys(t *testing.T) {
+	writeConfigFile(t, `
+version: 3
+active_profile: cloud
+profiles:
+  cloud:
+    host: https://cli.getconvoy.io
+    api_key_ref: keyring:missing
+`)
+
+	_, err := LoadConfig(ConfigOverrides{})
+	require.ErrorContains(t, err, "failed to load the api key of profile cloud")
+
+	c, err := ReadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"cloud"}, c.ProfileNames())
+	require.Equal(t, "https://cli.getconvoy.io", c.Profiles["cloud"].Host)
+	require.Empty(t, c.Profiles["cloud"].ActiveApiKey)
+}
+
+func TestNewConfig_SelectsProfile(t *testing.T) {
+	data := `
+active_profile: cloud
+profiles:
+  cloud:
+    host: https://cli.getconvoy.io
+    active_api_key: cloud-key
+  local:
+    host: http://localhost:5005
+    active_api_key: local-key
+`
+
+	tests := []struct {
+		name         string
+		profile      string
+		host         string
+		wantProfile  string
+		wantKey      string
+		wantProfiles []string
+	}{
+		{
+			name:         "active profile without a host",
+			wantProfile:  "cloud",
+			wantKey:      "cloud-key",
+			wantProfiles: []string{"cloud", "local"},
+		},
+		{
+			name:         "profile logged into the host",
+			host:         "http://localhost:5005",
+			wantProfile:  "local",
+			wantKey:      "local-key",
+			wantProfiles: []string{"cloud", "local"},
+		},
+		{
+			name:         "new host",
+			host:         "https://convoy.staging.example.com",
+			wantProfile:  "convoy.staging.example.com",
+			wantProfiles: []string{"cloud", "convoy.staging.example.com", "local"},
+		},
+		{
+			name:         "named profile",
+			profile:      "staging",
+			host:         "https://convoy.staging.example.com",
+			wantProfile:  "staging",
+			wantProfiles: []string{"cloud", "local", "staging"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, data)
+
+			c, err := NewConfig(ConfigOverrides{Profile: tt.profile, Host: tt.host})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantProfile, c.ProfileName())
+			require.Equal(t, tt.wantKey, c.ActiveApiKey)
+			require.Equal(t, tt.wantProfiles, c.ProfileNames())
+		})
+	}
+}
+
+func TestConfig_SelectProfile(t *testing.T) {
+	data := `
+active_profile: cloud
+profiles:
+  cloud:
+    host: https://cli.getconvoy.io
+    active_api_key: cloud-key
+  local:
+    host: http://localhost:5005
+    active_api_key: local-key
+`
+
+	tests := []struct {
+		name        string
+		overrides   ConfigOverrides
+		env         map[string]string
+		wantProfile string
+		wantErr     string
+	}{
+		{name: "active profile", wantProfile: "cloud"},
+		{name: "profile flag", overrides: ConfigOverrides{Profile: "local"}, wantProfile: "local"},
+		{name: "host flag", overrides: ConfigOverrides{Host: "http://localhost:5005"}, wantProfile: "local"},
+		{name: "profile env", env: map[string]string{ProfileEnv: "local"}, wantProfile: "local"},
+		{name: "host env", env: map[string]string{HostEnv: "http://localhost:5005"}, wantProfile: "local"},
+		{
+			name:        "flag over env",
+			overrides:   ConfigOverrides{Profile: "cloud"},
+			env:         map[string]string{ProfileEnv: "local"},
+			wantProfile: "cloud",
+		},
+		{name: "unknown host", env: map[string]string{HostEnv: "https://example.com"}, wantErr: "no profile is logged into https://example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, data)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			c, err := LoadConfig(ConfigOverrides{})
+			require.NoError(t, err)
+
+			name, err := c.SelectProfile(tt.overrides)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantProfile, name)
+		})
+	}
+}
+
+func TestConfig_RenameAndDeleteProfile(t *testing.T) {
+	writeConfigFile(t, `
+active_profile: cloud
+profiles:
+  cloud:
+    host: https://cli.getconvoy.io
+  local:
+    host: http://localhost:5005
+`)
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+
+	require.Error(t, c.RenameProfile("cloud", "local"))
+	require.Error(t, c.RenameProfile("staging", "dev"))
+
+	require.NoError(t, c.RenameProfile("cloud", "prod"))
+	require.Equal(t, "prod", c.ActiveProfile)
+	require.Equal(t, "prod", c.ProfileName())
+	require.Equal(t, []string{"local", "prod"}, c.ProfileNames())
+
+	require.NoError(t, c.DeleteProfile("prod"))
+	require.Equal(t, "local", c.ActiveProfile)
+	require.Error(t, c.DeleteProfile("prod"))
+
+	require.NoError(t, c.DeleteProfile("local"))
+	require.Empty(t, c.ActiveProfile)
+	require.NoError(t, c.WriteToDisk())
+
+	// a config without profiles isn't logged in
+	c, err = LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.Empty(t, c.Host)
+}
+
+func TestConfig_WriteToDiskKeepsBackup(t *testing.T) {
+	path := writeConfigFile(t, `
+active_profile: cloud
+profiles:
+  cloud:
+    host: https://cli.getconvoy.io
+    active_project_id: p1
+`)
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	c.ActiveProjectID = "p2"
+	require.NoError(t, c.WriteToDisk())
+
+	backup, err := os.ReadFile(path + backupSuffix)
+	require.NoError(t, err)
+	require.Equal(t, string(before), string(backup))
+
+	// a corrupt file points to the backup
+	require.NoError(t, os.WriteFile(path, []byte("profiles: [\n"), 0600))
+	_, err = LoadConfig(ConfigOverrides{})
+	require.ErrorContains(t, err, "is corrupt")
+	require.ErrorContains(t, err, path+backupSuffix)
+
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+	_, err = LoadConfig(ConfigOverrides{})
+	require.ErrorContains(t, err, "is corrupt")
+}
+
+func TestConfig_FindProject(t *testing.T) {
+	c := &Config{Profile: &Profile{Projects: []ConfigProject{
+		{UID: "p1", Name: "payments"},
+		{UID: "p2", Name: "p1"},
+	}}}
+
+	tests := []struct {
+		idOrName string
+		want     string
+	}{
+		{idOrName: "p1", want: "p1"},
+		{idOrName: " P2 ", want: "p2"},
+		{idOrName: "Payments", want: "p1"},
+		{idOrName: "billing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.idOrName, func(t *testing.T) {
+			p := c.FindProject(tt.idOrName)
+			if tt.want == "" {
+				require.Nil(t, p)
+				return
+			}
+			require.Equal(t, tt.want, p.UID)
+		})
+	}
+
+	// the project is the one in the config, not a copy
+	c.FindProject("p1").DeviceID = "d1"
+	require.Equal(t, "d1", c.Projects[0].DeviceID)
+}
@@ -0,0 +1,314 @@
+package convoy_cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ControlStatus = "status"
+	ControlPause  = "pause"
+	ControlResume = "resume"
+	ControlStop   = "stop"
+
+	defaultDaemonDir = ".convoy"
+
+	// Time allowed for the daemon to answer a control command.
+	controlTimeout = 5 * time.Second
+
+	// Events a stream buffers while the listener is paused, the server
+	// redelivers the ones received once the buffer is full.
+	maxQueuedEvents = 1000
+)
+
+var ErrDaemonNotRunning = errors.New("no background listener is running, start one with `convoy-cli listen --detach`")
+
+// DaemonPaths are the files of a listener running in the background.
+type DaemonPaths struct {
+	Socket string
+	PID    string
+	Log    string
+}
+
+func DefaultDaemonPaths() (*DaemonPaths, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(homedir, defaultDaemonDir)
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &DaemonPaths{
+		Socket: filepath.Join(dir, "listen.sock"),
+		PID:    filepath.Join(dir, "listen.pid"),
+		Log:    filepath.Join(dir, "listen.log"),
+	}, nil
+}
+
+func (p *DaemonPaths) WritePID() error {
+	return os.WriteFile(p.PID, []byte(strconv.Itoa(os.Getpid())+"\n"), 0600)
+}
+
+// Remove deletes the pid file and control socket.
+func (p *DaemonPaths) Remove() {
+	_ = os.Remove(p.Socket)
+	_ = os.Remove(p.PID)
+}
+
+type ControlRequest struct {
+	Command string `json:"command"`
+}
+
+type ControlResponse struct {
+	Error  string          `json:"error,omitempty"`
+	Status *ListenerStatus `json:"status,omitempty"`
+}
+
+type ListenerStatus struct {
+	PID     int            `json:"pid"`
+	Started time.Time      `json:"started"`
+	Ready   bool           `json:"ready"`
+	Paused  bool           `json:"paused"`
+	Streams []StreamStatus `json:"streams"`
+	Stats   string         `json:"stats"`
+}
+
+type StreamStatus struct {
+	Project   string `json:"project"`
+	Source    string `json:"source"`
+	ForwardTo string `json:"forward_to,omitempty"`
+	Connected bool   `json:"connected"`
+}
+
+// Status describes the session, e.g. for `listen status`.
+func (l *Listener) Status() *ListenerStatus {
+	status := &ListenerStatus{
+		PID:     os.Getpid(),
+		Started: l.stats.started,
+		Ready:   l.ready.Load(),
+		Paused:  l.Paused(),
+		Streams: []StreamStatus{},
+		Stats:   l.stats.Line(),
+	}
+
+	for _, s := range l.currentStreams() {
+		listenRequest := s.request.Load()
+		status.Streams = append(status.Streams, StreamStatus{
+			Project:   listenRequest.projectName(),
+			Source:    listenRequest.SourceName,
+			ForwardTo: listenRequest.ForwardTo,
+			Connected: s.connected.Load(),
+		})
+	}
+
+	return status
+}
+
+// Pause stops delivering events until Resume is called. The connections are
+// kept alive and read, events sent by the server in the meantime are buffered
+// and delivered on resume.
+func (l *Listener) Pause() {
+	l.pauseMu.Lock()
+	defer l.pauseMu.Unlock()
+
+	if l.resumed == nil {
+		l.resumed = make(chan struct{})
+		log.Println("listener paused")
+	}
+}
+
+func (l *Listener) Resume() {
+	l.pauseMu.Lock()
+	defer l.pauseMu.Unlock()
+
+	if l.resumed != nil {
+		close(l.resumed)
+		l.resumed = nil
+		log.Println("listener resumed")
+	}
+}
+
+func (l *Listener) Paused() bool {
+	l.pauseMu.Lock()
+	defer l.pauseMu.Unlock()
+
+	return l.resumed != nil
+}
+
+// waitResumed blocks while the listener is paused, or until the session ends.
+func (l *Listener) waitResumed() {
+	l.pauseMu.Lock()
+	resumed := l.resumed
+	l.pauseMu.Unlock()
+
+	if resumed == nil {
+		return
+	}
+
+	select {
+	case <-resumed:
+	case <-l.finished:
+	}
+}
+
+// enqueue buffers an event read while the listener is paused, or while the
+// events buffered before it are delivered, so they stay in order. It reports
+// whether the event was taken, the caller handles it otherwise.
+func (l *Listener) enqueue(s *stream, event *CLIEvent) bool {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	if !s.flushing && !l.Paused() {
+		return false
+	}
+
+	if len(s.queue) >= maxQueuedEvents {
+		l.skip(s, event, "the buffer of the paused listener is full")
+		return true
+	}
+
+	s.queue = append(s.queue, event)
+	if !s.flushing {
+		s.flushing = true
+		go l.flush(s)
+	}
+	return true
+}
+
+// flush delivers the buffered events of a stream once the listener resumes.
+func (l *Listener) flush(s *stream) {
+	for {
+		l.waitResumed()
+
+		s.queueMu.Lock()
+		if len(s.queue) == 0 {
+			s.flushing = false
+			s.queueMu.Unlock()
+			return
+		}
+
+		event := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.queueMu.Unlock()
+
+		l.process(s, event)
+	}
+}
+
+// ServeControl accepts control commands on the unix socket at path, the
+// returned function stops accepting them and removes the socket.
+func (l *Listener) ServeControl(path string) (func(), error) {
+	// a socket left behind by a listener that was killed
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					log.WithError(err).Errorln("control socket stopped")
+				}
+				return
+			}
+
+			go l.handleControl(conn)
+		}
+	}()
+
+	return func() {
+		ln.Close()
+		wg.Wait()
+		_ = os.Remove(path)
+	}, nil
+}
+
+func (l *Listener) handleControl(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(controlTimeout))
+
+	var req ControlRequest
+	res := &ControlResponse{}
+
+	err := json.NewDecoder(conn).Decode(&req)
+	if err != nil {
+		res.Error = fmt.Sprintf("invalid control request: %v", err)
+	} else {
+		switch req.Command {
+		case ControlStatus:
+		case ControlPause:
+			l.Pause()
+		case ControlResume:
+			l.Resume()
+		case ControlStop:
+			log.Println("stop requested on the control socket")
+			l.finish(l.unmet("stopped"))
+		default:
+			res.Error = fmt.Sprintf("unknown control command %q", req.Command)
+		}
+	}
+
+	if res.Error == "" {
+		res.Status = l.Status()
+	}
+
+	err = json.NewEncoder(conn).Encode(res)
+	if err != nil {
+		log.WithError(err).Errorln("failed to answer control request")
+	}
+}
+
+// SendControl sends command to the listener serving the control socket at
+// path and returns the listener's status after the command was applied.
+func SendControl(path, command string) (*ListenerStatus, error) {
+	conn, err := net.DialTimeout("unix", path, controlTimeout)
+	if err != nil {
+		return nil, ErrDaemonNotRunning
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(controlTimeout))
+
+	err = json.NewEncoder(conn).Encode(&ControlRequest{Command: command})
+	if err != nil {
+		return nil, err
+	}
+
+	var res ControlResponse
+	err = json.NewDecoder(conn).Decode(&res)
+	if err != nil {
+		return nil, fmt.Errorf("invalid control response: %v", err)
+	}
+
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+
+	return res.Status, nil
+}
@@ -0,0 +1,105 @@
+package convoy_cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeControl(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listen.sock")
+
+	l := NewListener(&Config{})
+	stop, err := l.ServeControl(path)
+	require.NoError(t, err)
+
+	status, err := SendControl(path, ControlStatus)
+	require.NoError(t, err)
+	require.Equal(t, os.Getpid(), status.PID)
+	require.False(t, status.Paused)
+
+	status, err = SendControl(path, ControlPause)
+	require.NoError(t, err)
+	require.True(t, status.Paused)
+	require.True(t, l.Paused())
+
+	status, err = SendControl(path, ControlResume)
+	require.NoError(t, err)
+	require.False(t, status.Paused)
+
+	_, err = SendControl(path, "restart")
+	require.EqualError(t, err, `unknown control command "restart"`)
+
+	_, err = SendControl(path, ControlStop)
+	require.NoError(t, err)
+	require.NoError(t, l.err)
+	select {
+	case <-l.finished:
+	default:
+		t.Fatal("stop did not end the session")
+	}
+
+	stop()
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+
+	_, err = SendControl(path, ControlStatus)
+	require.ErrorIs(t, err, ErrDaemonNotRunning)
+}
+
+func TestListener_PauseKeepsReading(t *testing.T) {
+	forwarded := make(chan string, 2)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data struct{ N string }
+		_ = json.NewDecoder(r.Body).Decode(&data)
+		forwarded <- data.N
+	}))
+	defer target.Close()
+
+	pong := make(chan struct{}, 1)
+	host := newFakeStreamServer(t, func(conn *websocket.Conn) {
+		conn.SetPongHandler(func(string) error {
+			pong <- struct{}{}
+			return nil
+		})
+
+		for _, msg := range []string{
+			`{"uid": "e1", "data": {"n": "1"}}`,
+			`{"uid": "e2", "data": {"n": "2"}}`,
+		} {
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(msg)))
+		}
+		require.NoError(t, conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)))
+
+		readUntilClosed(conn)
+	})
+
+	l := newTestListener()
+	l.Pause()
+	done := listenAsync(l, &ListenRequest{ProjectID: "p1", ForwardTo: target.URL, Until: &Until{Count: 2}}, host)
+
+	// a paused listener still answers the server's pings
+	select {
+	case <-pong:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the paused listener stopped reading")
+	}
+
+	select {
+	case n := <-forwarded:
+		t.Fatalf("event %s forwarded while paused", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	l.Resume()
+	require.NoError(t, waitListen(t, done))
+	require.Equal(t, "1", <-forwarded)
+	require.Equal(t, "2", <-forwarded)
+}
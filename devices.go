@@ -0,0 +1,153 @@
+package convoy_cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frain-dev/convoy-cli/util"
+)
+
+type devicesResponse struct {
+	Devices []Device `json:"devices"`
+}
+
+type updateDeviceRequest struct {
+	HostName string `json:"host_name"`
+}
+
+// ListDevices returns the cli devices registered in the projects of the api
+// key, sorted by project then name.
+func (c *Client) ListDevices() ([]Device, error) {
+	devices, _, err := c.listDevices()
+	return devices, err
+}
+
+// listDevices is ListDevices with the headers of the response.
+func (c *Client) listDevices() ([]Device, http.Header, error) {
+	resp, err := c.send(http.MethodGet, "/stream/devices", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = checkSupported(resp, "listing devices"); err != nil {
+		return nil, resp.ResponseHeader, err
+	}
+
+	if err = checkResponse(resp); err != nil {
+		return nil, resp.ResponseHeader, err
+	}
+
+	var r devicesResponse
+	err = json.Unmarshal(resp.Body, &r)
+	if err != nil {
+		return nil, resp.ResponseHeader, err
+	}
+
+	sort.SliceStable(r.Devices, func(i, j int) bool {
+		a, b := r.Devices[i], r.Devices[j]
+		if a.ProjectID != b.ProjectID {
+			return a.ProjectID < b.ProjectID
+		}
+		return a.HostName < b.HostName
+	})
+
+	return r.Devices, resp.ResponseHeader, nil
+}
+
+// RenameDevice changes the name the dashboard shows for a device.
+func (c *Client) RenameDevice(deviceID, name string) (*Device, error) {
+	if util.IsStringEmpty(name) {
+		return nil, errors.New("device name cannot be empty")
+	}
+
+	body, err := json.Marshal(&updateDeviceRequest{HostName: name})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(http.MethodPut, "/stream/devices/"+url.PathEscape(deviceID), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkSupported(resp, "renaming devices"); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("device %s not found", deviceID)
+	}
+
+	if err = checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var d Device
+	err = json.Unmarshal(resp.Body, &d)
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// StaleDevices returns the devices last seen before the cutoff, except the
+// ones in keep, e.g. the devices of this machine. Devices never seen, e.g.
+// registered by a login that is yet to listen, are only stale with
+// includeNeverSeen.
+func StaleDevices(devices []Device, cutoff time.Time, keep map[string]bool, includeNeverSeen bool) []Device {
+	var stale []Device
+	for _, d := range devices {
+		if keep[d.UID] {
+			continue
+		}
+
+		if d.LastSeenAt <= 0 {
+			if includeNeverSeen {
+				stale = append(stale, d)
+			}
+			continue
+		}
+
+		if d.LastSeenAt.Time().Before(cutoff) {
+			stale = append(stale, d)
+		}
+	}
+	return stale
+}
+
+// ParseAge parses a duration like time.ParseDuration, with d for days, e.g.
+// 30d or 12h.
+func ParseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q, e.g. 30d or 12h", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q, e.g. 30d or 12h", s)
+	}
+	return d, nil
+}
+
+// DeviceIDs returns the ids of this machine's devices in the profile in use.
+func (c *Config) DeviceIDs() map[string]bool {
+	ids := map[string]bool{}
+	for _, p := range c.Projects {
+		if !util.IsStringEmpty(p.DeviceID) {
+			ids[p.DeviceID] = true
+		}
+	}
+	return ids
+}
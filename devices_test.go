@@ -0,0 +1,136 @@
+package convoy_cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestClient_Devices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /stream/devices":
+			fmt.Fprint(w, `{"devices": [
+				{"uid": "d2", "project_id": "p2", "host_name": "laptop", "status": "online", "last_seen_at": "2023-01-02T15:04:05Z"},
+				{"uid": "d3", "project_id": "p1", "host_name": "server", "status": "offline"},
+				{"uid": "d1", "project_id": "p1", "host_name": "laptop", "status": "online"}
+			]}`)
+		case "PUT /stream/devices/d1":
+			var req updateDeviceRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			fmt.Fprintf(w, `{"uid": "d1", "host_name": %q}`, req.HostName)
+		case "PUT /stream/devices/d9":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"status": false, "message": "device not found"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key")
+	require.NoError(t, err)
+
+	devices, err := client.ListDevices()
+	require.NoError(t, err)
+	require.Len(t, devices, 3)
+	require.Equal(t, []string{"d1", "d3", "d2"}, []string{devices[0].UID, devices[1].UID, devices[2].UID})
+	require.Equal(t, time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC), devices[2].LastSeenAt.Time().UTC())
+
+	d, err := client.RenameDevice("d1", "work laptop")
+	require.NoError(t, err)
+	require.Equal(t, "work laptop", d.HostName)
+
+	_, err = client.RenameDevice("d9", "work laptop")
+	require.ErrorContains(t, err, "not found")
+
+	_, err = client.RenameDevice("d1", "")
+	require.Error(t, err)
+}
+
+func TestClient_DevicesUnsupported(t *testing.T) {
+	// hosts before CLIAPIVersion only route /stream/login and /stream/listen
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key")
+	require.NoError(t, err)
+
+	_, err = client.ListDevices()
+	require.ErrorIs(t, err, ErrUnsupported)
+	require.ErrorContains(t, err, "listing devices requires Convoy "+CLIAPIVersion)
+
+	_, err = client.RenameDevice("d1", "work laptop")
+	require.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestStaleDevices(t *testing.T) {
+	now := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	devices := []Device{
+		{UID: "recent", LastSeenAt: primitive.NewDateTimeFromTime(now.Add(-time.Hour))},
+		{UID: "old", LastSeenAt: primitive.NewDateTimeFromTime(now.Add(-40 * 24 * time.Hour))},
+		{UID: "never"},
+		{UID: "ours", LastSeenAt: primitive.NewDateTimeFromTime(now.Add(-40 * 24 * time.Hour))},
+	}
+
+	stale := StaleDevices(devices, now.Add(-30*24*time.Hour), map[string]bool{"ours": true}, false)
+	require.Len(t, stale, 1)
+	require.Equal(t, "old", stale[0].UID)
+
+	stale = StaleDevices(devices, now.Add(-30*24*time.Hour), map[string]bool{"ours": true}, true)
+	require.Len(t, stale, 2)
+	require.Equal(t, "old", stale[0].UID)
+	require.Equal(t, "never", stale[1].UID)
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		age     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{age: "30d", want: 30 * 24 * time.Hour},
+		{age: "12h", want: 12 * time.Hour},
+		{age: "90m", want: 90 * time.Minute},
+		{age: "d", wantErr: true},
+		{age: "-1d", wantErr: true},
+		{age: "month", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.age, func(t *testing.T) {
+			got, err := ParseAge(tt.age)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfig_EnsureMachineID(t *testing.T) {
+	writeConfigFile(t, settingsConfig)
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+
+	id := c.EnsureMachineID()
+	require.NotEmpty(t, id)
+	require.Equal(t, id, c.EnsureMachineID())
+	require.NoError(t, c.WriteToDisk())
+
+	c, err = LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.Equal(t, id, c.MachineID)
+}
@@ -0,0 +1,495 @@
+package convoy_cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	stdnet "net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frain-dev/convoy-cli/util"
+	"github.com/gorilla/websocket"
+)
+
+// MinServerVersion is the oldest Convoy version this cli works with.
+const MinServerVersion = "v0.8.0"
+
+// VersionHeader is the response header in which the host reports its version.
+const VersionHeader = "X-Convoy-Version"
+
+// Clock skews above these make timestamps, e.g. of listen --since, unreliable.
+const (
+	clockSkewWarn = 30 * time.Second
+	clockSkewFail = 5 * time.Minute
+)
+
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+	CheckSkip CheckStatus = "skip"
+)
+
+// Check is the result of one step of Doctor.
+type Check struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail,omitempty"`
+	Hint   string      `json:"hint,omitempty"`
+}
+
+// Doctor checks step by step that the cli can reach its host and listen,
+// a failed step skips the ones that depend on it.
+type Doctor struct {
+	Overrides ConfigOverrides
+
+	// ForwardTo is the target to check, the config's listen default when empty
+	ForwardTo  string
+	SourceName string
+	Timeout    time.Duration
+
+	// RootCAs verify the host's certificate in the tls check, the system pool when nil
+	RootCAs *x509.CertPool
+
+	// Proxy returns the proxy requests to the host go through, like the
+	// environment's HTTPS_PROXY, HTTP_PROXY and NO_PROXY when nil
+	Proxy func(*http.Request) (*url.URL, error)
+
+	c           *Config
+	host        *url.URL
+	proxy       *url.URL
+	cert        *x509.Certificate
+	loginHeader http.Header
+	now         func() time.Time
+}
+
+type doctorStep struct {
+	name string
+	run  func() Check
+
+	// needs are the steps that must not fail for this one to run
+	needs []string
+}
+
+// Run runs every check in order, report is called with each result as soon
+// as it is known.
+func (d *Doctor) Run(ctx context.Context, report func(Check)) []Check {
+	if d.Timeout == 0 {
+		d.Timeout = 10 * time.Second
+	}
+	if d.now == nil {
+		d.now = time.Now
+	}
+	if d.Proxy == nil {
+		d.Proxy = http.ProxyFromEnvironment
+	}
+
+	steps := []doctorStep{
+		{name: "config", run: d.checkConfig},
+		// the network checks connect through the proxy, like the requests to the host
+		{name: "proxy", run: d.checkProxy, needs: []string{"config"}},
+		{name: "dns", run: func() Check { return d.checkDNS(ctx) }, needs: []string{"proxy"}},
+		{name: "tcp", run: func() Check { return d.checkTCP(ctx) }, needs: []string{"dns"}},
+		{name: "tls", run: func() Check { return d.checkTLS(ctx) }, needs: []string{"tcp"}},
+		{name: "certificate", run: d.checkCertificate, needs: []string{"tls"}},
+		{name: "api key", run: d.checkApiKey, needs: []string{"tls"}},
+		{name: "websocket", run: d.checkWebsocket, needs: []string{"api key"}},
+		{name: "clock", run: d.checkClock, needs: []string{"api key"}},
+		{name: "forward target", run: func() Check { return d.checkForwardTarget(ctx) }, needs: []string{"config"}},
+		{name: "server version", run: d.checkServerVersion, needs: []string{"api key"}},
+	}
+
+	status := map[string]CheckStatus{}
+	checks := make([]Check, 0, len(steps))
+	for _, step := range steps {
+		var check Check
+		for _, need := range step.needs {
+			if status[need] == CheckFail || status[need] == CheckSkip {
+				check = Check{Status: CheckSkip, Detail: fmt.Sprintf("the %s check didn't pass", need)}
+				break
+			}
+		}
+
+		if check.Status == "" {
+			check = step.run()
+		}
+		check.Name = step.name
+
+		status[step.name] = check.Status
+		checks = append(checks, check)
+		if report != nil {
+			report(check)
+		}
+	}
+
+	return checks
+}
+
+func (d *Doctor) checkConfig() Check {
+	c, err := LoadConfig(d.Overrides)
+	if err != nil {
+		return Check{Status: CheckFail, Detail: err.Error(), Hint: "run `convoy-cli login`, or `convoy-cli config edit` to fix the config file"}
+	}
+	d.c = c
+
+	if util.IsStringEmpty(c.Host) || util.IsStringEmpty(c.ActiveApiKey) {
+		return Check{Status: CheckFail, Detail: "the profile has no host or api key", Hint: "run `convoy-cli login`"}
+	}
+
+	d.host, err = url.Parse(c.Host)
+	if err != nil || util.IsStringEmpty(d.host.Hostname()) {
+		return Check{Status: CheckFail, Detail: fmt.Sprintf("invalid host %q", c.Host), Hint: "run `convoy-cli config set host <url>`"}
+	}
+
+	detail := fmt.Sprintf("profile %s, host %s", firstNonEmpty(c.ProfileName(), "from the environment"), c.Host)
+	if len(c.Projects) == 0 {
+		return Check{Status: CheckWarn, Detail: detail + ", no projects", Hint: "run `convoy-cli project refresh`"}
+	}
+
+	if c.FindProject(c.ActiveProjectID) == nil {
+		return Check{Status: CheckWarn, Detail: detail + ", the active project isn't in the project list", Hint: "run `convoy-cli project use <id|name>`"}
+	}
+
+	return Check{Status: CheckPass, Detail: detail}
+}
+
+func (d *Doctor) checkDNS(ctx context.Context) Check {
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	addrs, err := stdnet.DefaultResolver.LookupHost(ctx, d.host.Hostname())
+	if err != nil {
+		if d.proxy != nil {
+			// the proxy resolves the host, it may know names this machine doesn't
+			return Check{Status: CheckWarn, Detail: err.Error() + ", the proxy resolves it instead"}
+		}
+		return Check{Status: CheckFail, Detail: err.Error(), Hint: "check the host name and your DNS settings"}
+	}
+
+	return Check{Status: CheckPass, Detail: fmt.Sprintf("%s resolves to %s", d.host.Hostname(), strings.Join(addrs, ", "))}
+}
+
+func (d *Doctor) checkTCP(ctx context.Context) Check {
+	start := time.Now()
+	conn, err := d.dial(ctx)
+	if err != nil {
+		return Check{Status: CheckFail, Detail: err.Error(), Hint: d.networkHint("check that the host is up and that a firewall doesn't block the port")}
+	}
+	defer conn.Close()
+
+	if d.proxy != nil {
+		return Check{Status: CheckPass, Detail: fmt.Sprintf("connected to %s through %s in %s", d.hostAddr(), d.proxy.Redacted(), time.Since(start).Round(time.Millisecond))}
+	}
+	return Check{Status: CheckPass, Detail: fmt.Sprintf("connected to %s in %s", conn.RemoteAddr(), time.Since(start).Round(time.Millisecond))}
+}
+
+func (d *Doctor) checkTLS(ctx context.Context) Check {
+	if d.host.Scheme != "https" {
+		return Check{Status: CheckWarn, Detail: "the host uses http, api keys are sent unencrypted", Hint: "use an https host outside local development"}
+	}
+
+	raw, err := d.dial(ctx)
+	if err != nil {
+		return Check{Status: CheckFail, Detail: err.Error(), Hint: d.networkHint("check that the host is up")}
+	}
+
+	conn := tls.Client(raw, &tls.Config{ServerName: d.host.Hostname(), RootCAs: d.RootCAs, MinVersion: tls.VersionTLS12})
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	err = conn.HandshakeContext(ctx)
+	if err != nil {
+		hint := "check the host's certificate"
+		var unknown x509.UnknownAuthorityError
+		if errors.As(err, &unknown) {
+			hint = "the certificate isn't signed by a trusted authority; behind a TLS-inspecting proxy, add its CA to the system trust store"
+		}
+		return Check{Status: CheckFail, Detail: err.Error(), Hint: hint}
+	}
+
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) > 0 {
+		d.cert = state.PeerCertificates[0]
+	}
+
+	return Check{Status: CheckPass, Detail: fmt.Sprintf("%s with %s", tlsVersion(state.Version), tls.CipherSuiteName(state.CipherSuite))}
+}
+
+func (d *Doctor) checkCertificate() Check {
+	if d.cert == nil {
+		return Check{Status: CheckSkip, Detail: "the host doesn't use tls"}
+	}
+
+	detail := fmt.Sprintf("%s, issued by %s, expires %s", d.cert.Subject.CommonName, d.cert.Issuer.CommonName, d.cert.NotAfter.Format(time.RFC3339))
+
+	left := d.cert.NotAfter.Sub(d.now())
+	if left < 14*24*time.Hour {
+		return Check{Status: CheckWarn, Detail: detail, Hint: fmt.Sprintf("the certificate expires in %d days, ask the host's admin to renew it", int(left.Hours()/24))}
+	}
+
+	return Check{Status: CheckPass, Detail: detail}
+}
+
+func (d *Doctor) checkProxy() Check {
+	proxy, err := d.Proxy(&http.Request{URL: d.host})
+	if err != nil {
+		return Check{Status: CheckFail, Detail: err.Error(), Hint: "fix HTTPS_PROXY, HTTP_PROXY and NO_PROXY"}
+	}
+
+	if proxy == nil {
+		return Check{Status: CheckPass, Detail: "no proxy is set for the host"}
+	}
+	d.proxy = proxy
+
+	return Check{Status: CheckPass, Detail: fmt.Sprintf("requests to the host go through %s", proxy.Redacted())}
+}
+
+func (d *Doctor) checkApiKey() Check {
+	k, err := d.c.verify()
+	if k != nil {
+		d.loginHeader = k.header
+	}
+	if err != nil {
+		var e *APIError
+		if errors.As(err, &e) && (e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden) {
+			return Check{Status: CheckFail, Detail: e.Error(), Hint: "the api key is invalid, expired or revoked, run `convoy-cli login`"}
+		}
+		return Check{Status: CheckFail, Detail: err.Error(), Hint: "check that the host is a Convoy instance"}
+	}
+
+	detail := fmt.Sprintf("%d devices", len(k.devices))
+	if k.projects >= 0 {
+		detail = fmt.Sprintf("%d projects", k.projects)
+	}
+	if !util.IsStringEmpty(k.userName) {
+		detail = fmt.Sprintf("logged in as %s, %s", k.userName, detail)
+	}
+	return Check{Status: CheckPass, Detail: detail}
+}
+
+func (d *Doctor) checkWebsocket() Check {
+	r := &ListenRequest{HostName: d.c.Host, SourceName: d.SourceName}
+	if p := d.c.FindProject(d.c.ActiveProjectID); p != nil {
+		r.ProjectID, r.DeviceID = p.UID, p.DeviceID
+	}
+
+	body, err := json.Marshal(r)
+	if err != nil {
+		return Check{Status: CheckFail, Detail: err.Error()}
+	}
+
+	dialer := *websocket.DefaultDialer
+	dialer.HandshakeTimeout = d.Timeout
+
+	conn, resp, err := dialer.Dial(listenURL(d.host), listenHeader(d.c.ActiveApiKey, body))
+	if err != nil {
+		hint := "a proxy or load balancer in front of the host may not allow websocket upgrades"
+		if resp != nil {
+			defer resp.Body.Close()
+			hint = fmt.Sprintf("the host answered %s", resp.Status)
+			if resp.StatusCode == http.StatusBadRequest && util.IsStringEmpty(d.SourceName) {
+				hint += ", pass the --source-name you listen to"
+			}
+		}
+		return Check{Status: CheckFail, Detail: err.Error(), Hint: hint}
+	}
+	defer conn.Close()
+
+	return Check{Status: CheckPass, Detail: "upgraded " + listenURL(d.host)}
+}
+
+func (d *Doctor) checkClock() Check {
+	date := d.loginHeader.Get("Date")
+	if util.IsStringEmpty(date) {
+		return Check{Status: CheckSkip, Detail: "the host didn't send a Date header"}
+	}
+
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return Check{Status: CheckSkip, Detail: fmt.Sprintf("invalid Date header %q", date)}
+	}
+
+	skew := d.now().Sub(serverTime).Round(time.Second)
+	detail := fmt.Sprintf("the local clock is %s off the host's", skew)
+
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+
+	switch {
+	case abs > clockSkewFail:
+		return Check{Status: CheckFail, Detail: detail, Hint: "sync your clock (e.g. with NTP), listen --since timestamps are off by as much"}
+	case abs > clockSkewWarn:
+		return Check{Status: CheckWarn, Detail: detail, Hint: "sync your clock (e.g. with NTP)"}
+	default:
+		return Check{Status: CheckPass, Detail: detail}
+	}
+}
+
+func (d *Doctor) checkForwardTarget(ctx context.Context) Check {
+	target := d.ForwardTo
+	if util.IsStringEmpty(target) && d.c.Listen != nil {
+		target = d.c.Listen.ForwardTo
+	}
+
+	if util.IsStringEmpty(target) {
+		return Check{Status: CheckSkip, Detail: "no forward target", Hint: "pass --forward-to, or set it with `convoy-cli config set listen.forward_to <url>`"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+	if err != nil {
+		return Check{Status: CheckFail, Detail: err.Error(), Hint: "the forward target must be a url, e.g. http://localhost:8080/webhooks"}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Check{Status: CheckFail, Detail: err.Error(), Hint: fmt.Sprintf("start your server at %s before listening", target)}
+	}
+	resp.Body.Close()
+
+	// any answer means something is listening, the events are POSTed
+	return Check{Status: CheckPass, Detail: fmt.Sprintf("%s answered %s", target, resp.Status)}
+}
+
+func (d *Doctor) checkServerVersion() Check {
+	version := d.loginHeader.Get(VersionHeader)
+	if util.IsStringEmpty(version) {
+		return Check{Status: CheckWarn, Detail: "the host doesn't report its version", Hint: fmt.Sprintf("the cli needs Convoy %s or newer", MinServerVersion)}
+	}
+
+	if compareVersions(version, MinServerVersion) < 0 {
+		return Check{Status: CheckFail, Detail: fmt.Sprintf("the host runs Convoy %s", version), Hint: fmt.Sprintf("the cli needs Convoy %s or newer, upgrade the host or use an older cli", MinServerVersion)}
+	}
+
+	return Check{Status: CheckPass, Detail: fmt.Sprintf("the host runs Convoy %s", version)}
+}
+
+func (d *Doctor) hostAddr() string {
+	return hostPort(d.host)
+}
+
+// hostPort returns the host and port of u, the scheme's port when it has none.
+func hostPort(u *url.URL) string {
+	port := u.Port()
+	if util.IsStringEmpty(port) {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return stdnet.JoinHostPort(u.Hostname(), port)
+}
+
+func (d *Doctor) networkHint(hint string) string {
+	if d.proxy != nil {
+		return hint + "; requests go through " + d.proxy.Redacted() + ", check that it is up and allows connecting to the host"
+	}
+	return hint
+}
+
+// dial connects to the host, with the CONNECT method of the proxy when
+// requests to the host go through one.
+func (d *Doctor) dial(ctx context.Context) (stdnet.Conn, error) {
+	dialer := &stdnet.Dialer{Timeout: d.Timeout}
+	if d.proxy == nil {
+		return dialer.DialContext(ctx, "tcp", d.hostAddr())
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", hostPort(d.proxy))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the proxy: %v", err)
+	}
+
+	if d.proxy.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: d.proxy.Hostname(), MinVersion: tls.VersionTLS12})
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(d.Timeout))
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: d.hostAddr()},
+		Host:   d.hostAddr(),
+		Header: http.Header{},
+	}
+	if u := d.proxy.User; u != nil {
+		password, _ := u.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(u.Username()+":"+password)))
+	}
+
+	err = req.Write(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect through the proxy: %v", err)
+	}
+
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect through the proxy: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("the proxy answered %s to connecting to %s", res.Status, d.hostAddr())
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// compareVersions compares versions like v1.2.3, ignoring suffixes like -rc1.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < 3; i++ {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) [3]int {
+	var parts [3]int
+
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+
+	for i, s := range strings.SplitN(v, ".", 3) {
+		parts[i], _ = strconv.Atoi(s)
+	}
+	return parts
+}
+
+func tlsVersion(v uint16) string {
+	switch v {
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	default:
+		return fmt.Sprintf("TLS 0x%x", v)
+	}
+}
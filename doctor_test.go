@@ -0,0 +1,282 @@
+package convoy_cli
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeConvoyServer(t *testing.T, version string, date time.Time, tls bool) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream/login", func(w http.ResponseWriter, r *http.Request) {
+		if version != "" {
+			w.Header().Set(VersionHeader, version)
+		}
+		w.Header().Set("Date", date.UTC().Format(http.TimeFormat))
+
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "invalid api key")
+			return
+		}
+		fmt.Fprint(w, `{"user_name": "Ada", "projects": [{"project": {"uid": "p1"}, "device": {"uid": "d1"}}]}`)
+	})
+	mux.HandleFunc("/stream/listen", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err == nil {
+			conn.Close()
+		}
+	})
+	mux.HandleFunc("/target", func(w http.ResponseWriter, r *http.Request) {})
+
+	server := httptest.NewUnstartedServer(mux)
+	if tls {
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+func doctorStatuses(checks []Check) map[string]CheckStatus {
+	statuses := map[string]CheckStatus{}
+	for _, c := range checks {
+		statuses[c.Name] = c.Status
+	}
+	return statuses
+}
+
+func TestDoctor_Run(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		apiKey    string
+		version   string
+		skew      time.Duration
+		forwardTo string
+		want      map[string]CheckStatus
+	}{
+		{
+			name:      "healthy",
+			apiKey:    "key",
+			version:   "v23.05.1",
+			forwardTo: "/target",
+			want: map[string]CheckStatus{
+				"config": CheckPass, "dns": CheckPass, "tcp": CheckPass, "tls": CheckWarn, "certificate": CheckSkip,
+				"proxy": CheckPass, "api key": CheckPass, "websocket": CheckPass, "clock": CheckPass,
+				"forward target": CheckPass, "server version": CheckPass,
+			},
+		},
+		{
+			name:    "invalid api key",
+			apiKey:  "revoked",
+			version: "v23.05.1",
+			want: map[string]CheckStatus{
+				"api key": CheckFail, "websocket": CheckSkip, "clock": CheckSkip, "forward target": CheckSkip, "server version": CheckSkip,
+			},
+		},
+		{
+			name:    "old server with a skewed clock",
+			apiKey:  "key",
+			version: "v0.7.2",
+			skew:    10 * time.Minute,
+			want:    map[string]CheckStatus{"clock": CheckFail, "server version": CheckFail},
+		},
+		{
+			name:      "unreachable forward target",
+			apiKey:    "key",
+			skew:      time.Minute,
+			forwardTo: "http://127.0.0.1:1/webhooks",
+			want:      map[string]CheckStatus{"clock": CheckWarn, "forward target": CheckFail, "server version": CheckWarn},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeConvoyServer(t, tt.version, now.Add(-tt.skew), false)
+
+			writeConfigFile(t, fmt.Sprintf(`
+active_profile: default
+profiles:
+  default:
+    host: %s
+    active_api_key: %s
+    active_project_id: p1
+    projects:
+      - uid: p1
+        name: payments
+        device_id: d1
+`, server.URL, tt.apiKey))
+
+			forwardTo := tt.forwardTo
+			if forwardTo == "/target" {
+				forwardTo = server.URL + forwardTo
+			}
+
+			d := &Doctor{ForwardTo: forwardTo, Timeout: time.Second, now: func() time.Time { return now }}
+
+			var reported []Check
+			checks := d.Run(context.Background(), func(c Check) { reported = append(reported, c) })
+			require.Equal(t, checks, reported)
+
+			statuses := doctorStatuses(checks)
+			for name, want := range tt.want {
+				require.Equal(t, want, statuses[name], name)
+			}
+		})
+	}
+}
+
+func TestDoctor_RunWithoutConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	checks := (&Doctor{}).Run(context.Background(), nil)
+	require.Equal(t, CheckFail, checks[0].Status)
+	for _, c := range checks[1:] {
+		require.Equal(t, CheckSkip, c.Status, c.Name)
+	}
+}
+
+func TestDoctor_TLS(t *testing.T) {
+	now := time.Now()
+	server := newFakeConvoyServer(t, "v23.05.1", now, true)
+
+	writeConfigFile(t, fmt.Sprintf(`
+active_profile: default
+profiles:
+  default:
+    host: %s
+    active_api_key: key
+`, server.URL))
+
+	// the test server's certificate isn't trusted by the system
+	statuses := doctorStatuses((&Doctor{Timeout: time.Second}).Run(context.Background(), nil))
+	require.Equal(t, CheckFail, statuses["tls"])
+	require.Equal(t, CheckSkip, statuses["certificate"])
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	checks := (&Doctor{Timeout: time.Second, RootCAs: pool}).Run(context.Background(), nil)
+	statuses = doctorStatuses(checks)
+	require.Equal(t, CheckPass, statuses["tls"])
+	require.Equal(t, CheckPass, statuses["certificate"])
+	require.Equal(t, CheckWarn, statuses["config"])
+}
+
+// newFakeConnectProxy tunnels CONNECT requests to their host, it sends the
+// address of each tunnel to connects and refuses the ones to refused.
+func newFakeConnectProxy(t *testing.T, refused string) (*url.URL, chan string) {
+	connects := make(chan string, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connects <- r.Host
+		if r.Method != http.MethodConnect || r.Host == refused {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		fmt.Fprint(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go io.Copy(upstream, conn)
+		io.Copy(conn, upstream)
+	}))
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return u, connects
+}
+
+func TestDoctor_Proxy(t *testing.T) {
+	server := newFakeConvoyServer(t, "v23.05.1", time.Now(), true)
+	host := server.Listener.Addr().String()
+
+	writeConfigFile(t, fmt.Sprintf(`
+active_profile: default
+profiles:
+  default:
+    host: %s
+    active_api_key: key
+`, server.URL))
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	tests := []struct {
+		name    string
+		refused string
+		want    map[string]CheckStatus
+	}{
+		{
+			name: "tunnel",
+			want: map[string]CheckStatus{"proxy": CheckPass, "tcp": CheckPass, "tls": CheckPass, "certificate": CheckPass},
+		},
+		{
+			name:    "refused",
+			refused: host,
+			want:    map[string]CheckStatus{"proxy": CheckPass, "tcp": CheckFail, "tls": CheckSkip},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy, connects := newFakeConnectProxy(t, tt.refused)
+
+			d := &Doctor{Timeout: time.Second, RootCAs: pool, Proxy: http.ProxyURL(proxy)}
+			checks := d.Run(context.Background(), nil)
+
+			statuses := doctorStatuses(checks)
+			for name, want := range tt.want {
+				require.Equal(t, want, statuses[name], name)
+			}
+
+			// the tcp and tls checks both connect through the proxy
+			require.Equal(t, host, <-connects)
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v0.8.0", "v0.8.0", 0},
+		{"0.8.1", "v0.8.0", 1},
+		{"v0.7.9", "v0.8.0", -1},
+		{"v23.05.1", "v0.8.0", 1},
+		{"v0.8.0-rc.1", "v0.8.0", 0},
+		{"v1", "v0.8.0", 1},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, compareVersions(tt.a, tt.b), "%s %s", tt.a, tt.b)
+	}
+}
@@ -0,0 +1,369 @@
+package convoy_cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression is a condition evaluated against an event, e.g.
+//
+//	data.status == "paid" && data.amount >= 100
+//
+// Paths are dot separated and resolved against the event's uid, event_type,
+// headers and data, numeric segments index into arrays. Operands on the right
+// hand side are json literals. Comparisons can be combined with &&, || and
+// parentheses. A bare path is true when it resolves to a value other than
+// null or false. The server doesn't stream the event type, event_type is
+// empty unless ListenRequest.EventTypeField reads it from the payload.
+type Expression struct {
+	src  string
+	root node
+}
+
+func ParseExpression(s string) (*Expression, error) {
+	p := &parser{tokens: tokenize(s)}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %v", s, err)
+	}
+
+	if !p.done() {
+		return nil, fmt.Errorf("invalid expression %q: unexpected %q", s, p.peek())
+	}
+
+	return &Expression{src: s, root: root}, nil
+}
+
+func (e *Expression) String() string {
+	return e.src
+}
+
+// Match reports whether the event satisfies the expression.
+func (e *Expression) Match(event *CLIEvent) bool {
+	return truthy(e.root.eval(event.document()))
+}
+
+// lookup resolves a dot separated path against the event the way
+// expressions do, e.g. data.type or headers.X-Event-Type.
+func (e *CLIEvent) lookup(path string) interface{} {
+	return pathNode(strings.Split(path, ".")).eval(e.document())
+}
+
+// document is the event as expressions see it.
+func (e *CLIEvent) document() map[string]interface{} {
+	doc := map[string]interface{}{
+		"uid":        e.UID,
+		"event_type": e.EventType,
+	}
+
+	headers := map[string]interface{}{}
+	for k, v := range e.Headers {
+		if len(v) > 0 {
+			headers[k] = v[0]
+		}
+	}
+	doc["headers"] = headers
+
+	var data interface{}
+	d := json.NewDecoder(bytes.NewReader(e.Data))
+	d.UseNumber()
+	if err := d.Decode(&data); err == nil {
+		doc["data"] = data
+	}
+
+	return doc
+}
+
+type node interface {
+	eval(doc interface{}) interface{}
+}
+
+type pathNode []string
+
+func (p pathNode) eval(doc interface{}) interface{} {
+	v := doc
+	for _, seg := range p {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = t[seg]; !ok {
+				// header names are case insensitive
+				v = lookupFold(t, seg)
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil
+			}
+			v = t[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func lookupFold(m map[string]interface{}, key string) interface{} {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
+type literalNode struct{ v interface{} }
+
+func (l literalNode) eval(interface{}) interface{} { return l.v }
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (b binaryNode) eval(doc interface{}) interface{} {
+	switch b.op {
+	case "&&":
+		return truthy(b.left.eval(doc)) && truthy(b.right.eval(doc))
+	case "||":
+		return truthy(b.left.eval(doc)) || truthy(b.right.eval(doc))
+	}
+
+	l, r := b.left.eval(doc), b.right.eval(doc)
+
+	switch b.op {
+	case "==":
+		return equal(l, r)
+	case "!=":
+		return !equal(l, r)
+	}
+
+	lf, lok := number(l)
+	rf, rok := number(r)
+	if lok && rok {
+		switch b.op {
+		case ">":
+			return lf > rf
+		case ">=":
+			return lf >= rf
+		case "<":
+			return lf < rf
+		case "<=":
+			return lf <= rf
+		}
+	}
+
+	ls, lok := l.(string)
+	rs, rok := r.(string)
+	if lok && rok {
+		switch b.op {
+		case ">":
+			return ls > rs
+		case ">=":
+			return ls >= rs
+		case "<":
+			return ls < rs
+		case "<=":
+			return ls <= rs
+		}
+	}
+
+	return false
+}
+
+func equal(l, r interface{}) bool {
+	lf, lok := number(l)
+	rf, rok := number(r)
+	if lok && rok {
+		return lf == rf
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func truthy(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	}
+	return true
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *parser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "||", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "&&", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch op := p.peek(); op {
+	case "==", "!=", ">", ">=", "<", "<=":
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return binaryNode{op: op, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	t := p.next()
+
+	switch {
+	case t == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case t == "(":
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return n, nil
+	case t == "true", t == "false", t == "null", t[0] == '"', t[0] == '-', unicode.IsDigit(rune(t[0])):
+		d := json.NewDecoder(strings.NewReader(t))
+		d.UseNumber()
+
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			return nil, fmt.Errorf("invalid literal %s", t)
+		}
+
+		// the decoder stops after the first value, e.g. at the 1 of 1abc
+		if _, err := d.Token(); err != io.EOF {
+			return nil, fmt.Errorf("invalid literal %s", t)
+		}
+		return literalNode{v: v}, nil
+	case isPathStart(rune(t[0])):
+		return pathNode(strings.Split(t, ".")), nil
+	}
+
+	return nil, fmt.Errorf("unexpected %q", t)
+}
+
+func isPathStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+func tokenize(s string) []string {
+	var tokens []string
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(s) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		case strings.ContainsRune("=!<>&|", rune(c)):
+			j := i + 1
+			for j < len(s) && strings.ContainsRune("=&|", rune(s[j])) && j-i < 2 {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\n()\"=!<>&|", rune(s[j])) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+
+	return tokens
+}
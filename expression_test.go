@@ -0,0 +1,51 @@
+package convoy_cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpression_Match(t *testing.T) {
+	event := &CLIEvent{
+		UID:       "evt-1",
+		EventType: "invoice.paid",
+		Headers:   map[string][]string{"X-Tenant": {"acme"}},
+		Data:      []byte(`{"status": "paid", "amount": 150, "items": [{"sku": "a-1"}], "refunded": false}`),
+	}
+
+	tests := []struct {
+		expr  string
+		match bool
+	}{
+		{`data.status == "paid"`, true},
+		{`data.status != "paid"`, false},
+		{`data.amount >= 100`, true},
+		{`data.amount < 100`, false},
+		{`data.amount == 150.0`, true},
+		{`data.items.0.sku == "a-1"`, true},
+		{`data.items.3.sku == "a-1"`, false},
+		{`event_type == "invoice.paid" && data.amount > 200`, false},
+		{`event_type == "invoice.paid" || data.amount > 200`, true},
+		{`(data.refunded || data.amount > 100) && uid == "evt-1"`, true},
+		{`headers.x-tenant == "acme"`, true},
+		{`data.refunded`, false},
+		{`data.missing`, false},
+		{`data.status`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := ParseExpression(tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.match, e.Match(event))
+		})
+	}
+}
+
+func TestParseExpression_Invalid(t *testing.T) {
+	for _, s := range []string{``, `data.status ==`, `(data.status == "paid"`, `data.status == "paid" )`, `== 1`, `data.amount == 1abc`, `data.amount > -1x`} {
+		_, err := ParseExpression(s)
+		require.Error(t, err, s)
+	}
+}
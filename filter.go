@@ -0,0 +1,54 @@
+package convoy_cli
+
+import (
+	"fmt"
+	"path"
+)
+
+// EventFilter selects the events a stream forwards. Events are matched
+// against glob patterns on their event type (e.g. invoice.*) and, when set,
+// an expression. Events that don't match are acknowledged without being forwarded.
+// The server doesn't stream the event type, events without one only match
+// the globs once ListenRequest.EventTypeField reads it from the payload.
+type EventFilter struct {
+	EventTypes []string
+	Match      *Expression
+}
+
+func NewEventFilter(eventTypes []string, match string) (*EventFilter, error) {
+	f := &EventFilter{EventTypes: eventTypes}
+
+	for _, pattern := range eventTypes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid event type pattern %q: %v", pattern, err)
+		}
+	}
+
+	if match != "" {
+		var err error
+		f.Match, err = ParseExpression(match)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func (f *EventFilter) Allows(event *CLIEvent) bool {
+	if len(f.EventTypes) > 0 {
+		found := false
+		for _, pattern := range f.EventTypes {
+			if ok, _ := path.Match(pattern, event.EventType); ok {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return f.Match == nil || f.Match.Match(event)
+}
@@ -0,0 +1,105 @@
+package convoy_cli
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventFilter_Allows(t *testing.T) {
+	tests := []struct {
+		name       string
+		eventTypes []string
+		match      string
+		event      CLIEvent
+		want       bool
+	}{
+		{
+			name:       "event type glob",
+			eventTypes: []string{"invoice.*"},
+			event:      CLIEvent{EventType: "invoice.paid"},
+			want:       true,
+		},
+		{
+			name:       "event type not listed",
+			eventTypes: []string{"invoice.*", "charge.succeeded"},
+			event:      CLIEvent{EventType: "charge.failed"},
+			want:       false,
+		},
+		{
+			name:  "expression",
+			match: "data.amount >= 100",
+			event: CLIEvent{Data: []byte(`{"amount":150}`)},
+			want:  true,
+		},
+		{
+			name:       "event type and expression",
+			eventTypes: []string{"invoice.*"},
+			match:      "data.amount >= 100",
+			event:      CLIEvent{EventType: "invoice.paid", Data: []byte(`{"amount":50}`)},
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewEventFilter(tt.eventTypes, tt.match)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, f.Allows(&tt.event))
+		})
+	}
+}
+
+func TestNewEventFilter_InvalidPattern(t *testing.T) {
+	_, err := NewEventFilter([]string{"invoice.["}, "")
+	require.Error(t, err)
+}
+
+func TestListener_FilterServerShapedEvents(t *testing.T) {
+	// convoy streams uid, headers and data, the event type isn't sent
+	host := newFakeStreamServer(t, func(conn *websocket.Conn) {
+		for _, msg := range []string{
+			`{"uid": "e1", "headers": {}, "data": {"type": "customer.created"}}`,
+			`{"uid": "e2", "headers": {}, "data": {"type": "invoice.paid"}}`,
+		} {
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(msg)))
+		}
+		readUntilClosed(conn)
+	})
+
+	var mu sync.Mutex
+	var forwarded []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var data struct{ Type string }
+		require.NoError(t, json.Unmarshal(body, &data))
+
+		mu.Lock()
+		forwarded = append(forwarded, data.Type)
+		mu.Unlock()
+	}))
+	defer target.Close()
+
+	filter, err := NewEventFilter([]string{"invoice.*"}, "")
+	require.NoError(t, err)
+
+	err = waitListen(t, listenAsync(newTestListener(), &ListenRequest{
+		ProjectID:      "p1",
+		ForwardTo:      target.URL,
+		Filter:         filter,
+		EventTypeField: "data.type",
+		Until:          &Until{Count: 1},
+	}, host))
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"invoice.paid"}, forwarded)
+}
@@ -0,0 +1,162 @@
+package convoy_cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLedgerPath = ".convoy/ledger"
+)
+
+// Ledger is a persistent record of the events that were successfully delivered
+// to the local target, used to suppress redelivered events. Entries are appended
+// to the ledger file as "<uid> <unix timestamp>" and expire after the ttl. The
+// file is shared by every listen, it is only written with its lock held.
+type Ledger struct {
+	path       string
+	ttl        time.Duration
+	mu         sync.Mutex
+	entries    map[string]time.Time
+	suppressed int
+}
+
+func DefaultLedgerPath() (string, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homedir, defaultLedgerPath), nil
+}
+
+// OpenLedger loads the ledger at path, dropping expired entries.
+func OpenLedger(path string, ttl time.Duration) (*Ledger, error) {
+	l := &Ledger{path: path, ttl: ttl, entries: map[string]time.Time{}}
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock ledger: %v", err)
+	}
+	defer unlock()
+
+	err = l.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// rewrite the file without the expired entries before appending to it
+	err = l.compact()
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *Ledger) load() error {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %v", err)
+	}
+	defer f.Close()
+
+	now := time.Now()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		uid, ts, found := strings.Cut(scanner.Text(), " ")
+		if !found {
+			continue
+		}
+
+		sec, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		at := time.Unix(sec, 0)
+		if now.Sub(at) > l.ttl {
+			continue
+		}
+
+		l.entries[uid] = at
+	}
+
+	return scanner.Err()
+}
+
+func (l *Ledger) compact() error {
+	err := os.MkdirAll(filepath.Dir(l.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for uid, at := range l.entries {
+		fmt.Fprintf(&b, "%s %d\n", uid, at.Unix())
+	}
+
+	return writeFileAtomic(l.path, []byte(b.String()), 0600)
+}
+
+// Seen reports whether the event was delivered within the ttl.
+func (l *Ledger) Seen(uid string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	at, ok := l.entries[uid]
+	return ok && time.Since(at) <= l.ttl
+}
+
+// Record marks the event as delivered. The file is opened for each record, so
+// the entry lands in the file another listen compacted in the meantime.
+func (l *Ledger) Record(uid string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.entries[uid] = now
+
+	unlock, err := lockFile(l.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(f, "%s %d\n", uid, now.Unix())
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// Suppress counts a duplicate event that was not delivered again.
+func (l *Ledger) Suppress() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.suppressed++
+}
+
+// Suppressed returns the number of duplicate events suppressed since the ledger was opened.
+func (l *Ledger) Suppressed() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.suppressed
+}
@@ -0,0 +1,71 @@
+package convoy_cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger")
+
+	l, err := OpenLedger(path, time.Hour)
+	require.NoError(t, err)
+
+	require.False(t, l.Seen("evt-1"))
+	require.NoError(t, l.Record("evt-1"))
+	require.True(t, l.Seen("evt-1"))
+
+	l.Suppress()
+	require.Equal(t, 1, l.Suppressed())
+
+	// entries survive a restart
+	l, err = OpenLedger(path, time.Hour)
+	require.NoError(t, err)
+	require.True(t, l.Seen("evt-1"))
+	require.Equal(t, 0, l.Suppressed())
+}
+
+func TestLedger_DropsExpiredEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger")
+
+	old := time.Now().Add(-2 * time.Hour).Unix()
+	data := fmt.Sprintf("evt-old %d\nevt-new %d\n", old, time.Now().Unix())
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+	l, err := OpenLedger(path, time.Hour)
+	require.NoError(t, err)
+
+	require.False(t, l.Seen("evt-old"))
+	require.True(t, l.Seen("evt-new"))
+
+	buf, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(buf), "evt-old")
+}
+
+func TestLedger_SharedBetweenListeners(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger")
+
+	a, err := OpenLedger(path, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, a.Record("evt-1"))
+
+	// a second listen compacts the file while the first one keeps recording
+	b, err := OpenLedger(path, time.Hour)
+	require.NoError(t, err)
+	require.True(t, b.Seen("evt-1"))
+
+	require.NoError(t, a.Record("evt-2"))
+	require.NoError(t, b.Record("evt-3"))
+
+	c, err := OpenLedger(path, time.Hour)
+	require.NoError(t, err)
+	for _, uid := range []string{"evt-1", "evt-2", "evt-3"} {
+		require.True(t, c.Seen(uid), uid)
+	}
+}
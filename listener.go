@@ -1,16 +1,25 @@
 package convoy_cli
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/frain-dev/convoy-cli/net"
 	"github.com/frain-dev/convoy-cli/util"
 	"github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -23,73 +32,300 @@ const (
 
 	// Send pings to server with this period. Must be less than pongWait.
 	pingPeriod = (pongWait * 9) / 10
+
+	// Time allowed for in-flight events to finish when shutting down.
+	defaultDrainTimeout = 10 * time.Second
 )
 
 type Listener struct {
-	done      chan interface{} // Channel to indicate that the receiverHandler is done
-	interrupt chan os.Signal   // Channel to listen for interrupt signal to terminate gracefully
+	interrupt chan os.Signal // Channel to listen for interrupt signal to terminate gracefully
 	c         *Config
+
+	streamsMu sync.RWMutex
+	streams   []*stream
+	ready     atomic.Bool // set once every stream is connected
+
+	finished   chan struct{} // Channel closed when the session should end without an interrupt
+	finishOnce sync.Once
+	err        error
+	stopped    chan struct{} // Channel closed when ListenAll returns
+
+	stats       *Stats
+	statsSignal chan os.Signal // Channel to listen for SIGUSR1 to print the session summary
+
+	metrics *Metrics
+	tracer  trace.Tracer
+
+	// handlers hold a read lock on inflight while delivering an event,
+	// draining takes the write lock to wait for them to finish
+	inflight sync.RWMutex
+	draining atomic.Bool
+
+	// resumed is closed when a paused listener resumes, nil while not paused
+	pauseMu sync.Mutex
+	resumed chan struct{}
+
+	reloader Reloader
+	reloads  chan struct{} // reload requests from the config watcher
+}
+
+// stream is the websocket connection receiving the events of one project and source.
+type stream struct {
+	request   atomic.Pointer[ListenRequest] // replaced when the session config is reloaded
+	host      *url.URL
+	conn      *websocket.Conn
+	done      chan interface{} // Channel to indicate that the receiverHandler is done
+	writeMu   sync.Mutex
+	connected atomic.Bool
+	log       *log.Entry
+	started   time.Time
+
+	// busy is held while an event is handled, retired is set when a
+	// reload replaces the stream
+	busy    sync.Mutex
+	retired atomic.Bool
+
+	// dispatcher forwards the stream's events to the local target
+	dispatcher *net.Dispatcher
+
+	// queue holds the events read while the listener is paused, flushing is
+	// set while they are delivered
+	queueMu  sync.Mutex
+	queue    []*CLIEvent
+	flushing bool
+
+	// untyped is set once an event without a type was warned about
+	untyped atomic.Bool
 }
 
 func NewListener(c *Config) *Listener {
 	return &Listener{
 		c:         c,
-		done:      make(chan interface{}),
-		interrupt: make(chan os.Signal),
+		interrupt: make(chan os.Signal, 1),
+		finished:  make(chan struct{}),
+		stopped:   make(chan struct{}),
+
+		stats:       NewStats(),
+		statsSignal: make(chan os.Signal, 1),
+		reloads:     make(chan struct{}, 1),
+
+		tracer: otel.Tracer(tracerName),
 	}
 }
 
-func (l *Listener) Listen(listenRequest *ListenRequest, hostInfo *url.URL) {
-	signal.Notify(l.interrupt, os.Interrupt) // Notify the interrupt channel for SIGINT
+// Listen streams events until the session is interrupted or, when
+// listenRequest.Until is set, until its condition is met. The returned error
+// is non nil when the session ended because the condition could not be met.
+func (l *Listener) Listen(listenRequest *ListenRequest, hostInfo *url.URL) error {
+	return l.ListenAll([]*ListenRequest{listenRequest}, hostInfo)
+}
 
-	body, err := json.Marshal(listenRequest)
-	if err != nil {
-		log.Fatal("Error marshalling json:", err)
+// ListenAll is Listen for several projects and sources at once, with one
+// websocket connection per listen request. Requests sharing an Until or a
+// Ledger share its state.
+func (l *Listener) ListenAll(listenRequests []*ListenRequest, hostInfo *url.URL) error {
+	notifyShutdown(l.interrupt) // Notify the interrupt channel for SIGINT, SIGTERM and SIGHUP
+	notifyStats(l.statsSignal)
+	defer func() {
+		signal.Stop(l.interrupt)
+		signal.Stop(l.statsSignal)
+		close(l.stopped)
+	}()
+
+	for _, listenRequest := range listenRequests {
+		s, err := l.dial(listenRequest, hostInfo)
+		if err != nil {
+			l.closeAll()
+			return err
+		}
+
+		l.streamsMu.Lock()
+		l.streams = append(l.streams, s)
+		l.streamsMu.Unlock()
 	}
+	l.ready.Store(true)
 
-	url := url.URL{
+	var statsInterval time.Duration
+	untils := map[*Until]struct{}{}
+	ledgers := map[*Ledger]struct{}{}
+	for _, r := range listenRequests {
+		if r.StatsInterval > statsInterval {
+			statsInterval = r.StatsInterval
+		}
+
+		if r.Until != nil {
+			untils[r.Until] = struct{}{}
+		}
+
+		if r.Ledger != nil {
+			ledgers[r.Ledger] = struct{}{}
+		}
+	}
+
+	for until := range untils {
+		if until.Timeout <= 0 {
+			continue
+		}
+
+		until := until
+		timer := time.AfterFunc(until.Timeout, func() {
+			if until.Count == 0 {
+				log.Printf("stopping after %v", until.Timeout)
+			}
+			l.finish(until.TimeoutError())
+		})
+		defer timer.Stop()
+	}
+
+	for _, s := range l.currentStreams() {
+		go l.HandleMessage(s)
+	}
+
+	go l.reportStats(statsInterval)
+	l.PingUntilInterrupt()
+
+	fmt.Print(l.stats.Summary())
+
+	for ledger := range ledgers {
+		log.Printf("suppressed %d duplicate events", ledger.Suppressed())
+	}
+
+	for until := range untils {
+		err := until.WriteOutput()
+		if err != nil {
+			log.WithError(err).Errorln("failed to write matched events")
+		}
+	}
+
+	// makes sure no late finish can overwrite the error we return
+	l.finish(nil)
+	return l.err
+}
+
+// listenURL is the websocket url listen connects to on the host.
+func listenURL(hostInfo *url.URL) string {
+	u := url.URL{
 		Scheme: "ws",
 		Host:   hostInfo.Host,
 		Path:   "/stream/listen",
 	}
+	return u.String()
+}
 
-	conn, response, err := websocket.DefaultDialer.Dial(url.String(), http.Header{
-		"Authorization": []string{"Bearer " + l.c.ActiveApiKey},
+// listenHeader carries the api key and the listen request to the host.
+func listenHeader(apiKey string, body []byte) http.Header {
+	return http.Header{
+		"Authorization": []string{"Bearer " + apiKey},
 		"Body":          []string{string(body)},
-	})
+	}
+}
+
+// dial opens the websocket connection for the listen request.
+func (l *Listener) dial(listenRequest *ListenRequest, hostInfo *url.URL) (*stream, error) {
+	s := &stream{
+		host: hostInfo,
+		done: make(chan interface{}),
+		log:  log.WithFields(log.Fields{"project": listenRequest.projectName(), "source": listenRequest.SourceName}),
+
+		started: time.Now(),
+	}
+	s.setRequest(listenRequest)
+
+	d, err := net.NewDispatcher(time.Second*10, "")
+	if err != nil {
+		return nil, err
+	}
+	s.dispatcher = d
+
+	body, err := json.Marshal(listenRequest)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling json: %v", err)
+	}
+
+	conn, response, err := websocket.DefaultDialer.Dial(listenURL(hostInfo), listenHeader(l.c.ActiveApiKey, body))
 
 	if err != nil {
 		if response != nil {
+			defer response.Body.Close()
+
 			buf, e := io.ReadAll(response.Body)
 			if e != nil {
-				log.Fatal("Error parsing request body", e)
+				return nil, fmt.Errorf("error parsing request body: %v", e)
 			}
-			defer response.Body.Close()
-			log.WithError(err).Fatalln("websocket dialer failed with response: ", string(buf))
+			return nil, fmt.Errorf("websocket dialer failed with response: %s: %v", string(buf), err)
 		}
 
-		log.Fatal(err)
+		return nil, err
 	}
 
+	s.conn = conn
 	if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-		return
+		conn.Close()
+		return nil, err
 	}
 
-	defer conn.Close()
+	l.setConnected(s, true)
 
 	if !util.IsStringEmpty(listenRequest.Since) {
 		// Send a message to the server to resend unsuccessful events to the device
-		err := conn.WriteMessage(websocket.TextMessage, []byte(listenRequest.Since))
+		err := s.writeMessage(websocket.TextMessage, []byte(listenRequest.Since))
 		if err != nil {
-			log.WithError(err).Errorln("an error occurred sending 'since' message")
+			s.log.WithError(err).Errorln("an error occurred sending 'since' message")
 		}
 	}
 
-	go l.HandleMessage(conn, listenRequest.ForwardTo)
-	l.PingUntilInterrupt(conn)
+	return s, nil
+}
+
+// currentStreams returns the streams opened so far, it is safe to call while dialing.
+func (l *Listener) currentStreams() []*stream {
+	l.streamsMu.RLock()
+	defer l.streamsMu.RUnlock()
+
+	return append([]*stream(nil), l.streams...)
 }
 
-func (l *Listener) PingUntilInterrupt(conn *websocket.Conn) {
+// reportStats logs a line of statistics every interval, and the full summary on SIGUSR1.
+func (l *Listener) reportStats(interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			log.Println(l.stats.Line())
+		case <-l.statsSignal:
+			fmt.Print(l.stats.Summary())
+		case <-l.finished:
+			return
+		}
+	}
+}
+
+// unmet returns an error when the until condition of a stream isn't met, so
+// a session ended early for reason doesn't pass for a successful one.
+func (l *Listener) unmet(reason string) error {
+	for _, s := range l.currentStreams() {
+		if until := s.request.Load().Until; until != nil && !until.Met() {
+			return until.UnmetError(reason)
+		}
+	}
+	return nil
+}
+
+// finish ends the session, err is returned by Listen.
+func (l *Listener) finish(err error) {
+	l.finishOnce.Do(func() {
+		l.err = err
+		close(l.finished)
+	})
+}
+
+func (l *Listener) PingUntilInterrupt() {
 	ticker := time.NewTicker(pingPeriod)
 	defer ticker.Stop()
 
@@ -98,95 +334,546 @@ func (l *Listener) PingUntilInterrupt(conn *websocket.Conn) {
 	for {
 		select {
 		case <-ticker.C:
-			err := conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err != nil {
-				log.WithError(err).Errorln("failed to set write deadline")
+			for _, s := range l.currentStreams() {
+				if err := s.writeMessage(websocket.PingMessage, nil); err != nil {
+					s.log.WithError(err).Errorln("failed to set write ping message")
+					l.finish(fmt.Errorf("the connection for %s failed: %v", s.request.Load().streamName(), err))
+					l.closeAll()
+					return
+				}
 			}
 
-			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-				log.WithError(err).Errorln("failed to set write ping message")
-				return
+		case <-l.reloads:
+			l.reload()
+
+		case sig := <-l.interrupt:
+			if l.reloader != nil && isReloadSignal(sig) {
+				log.Printf("Received %v signal. Reloading the session config", sig)
+				l.reload()
+				continue
 			}
 
-		case <-l.interrupt:
-			// We received a SIGINT (Ctrl + C). Terminate gracefully...
-			log.Println("Received SIGINT interrupt signal. Closing all pending connections")
+			// We received a SIGINT (Ctrl + C), SIGTERM or SIGHUP. Terminate gracefully...
+			log.Printf("Received %v signal. Closing all pending connections", sig)
+			l.finish(l.unmet(fmt.Sprintf("interrupted by %v", sig)))
 
-			// Send a message to set the device to offline
-			err := conn.WriteMessage(websocket.TextMessage, []byte("disconnect"))
-			if err != nil {
-				log.WithError(err).Errorln("error during closing websocket")
-				return
-			}
+			go func() {
+				select {
+				case sig := <-l.interrupt:
+					log.Fatalf("Received %v signal while draining. Exiting without waiting for in-flight events", sig)
+				case <-l.stopped:
+				}
+			}()
+
+			l.drain()
+			l.closeAll()
+			return
+
+		case <-l.finished:
+			l.drain()
+			l.closeAll()
+			return
+		}
+	}
+}
+
+// drain stops accepting new events and waits for in-flight events to be
+// delivered and acknowledged. Events received from here on are not acked,
+// so the server delivers them again on the next session.
+func (l *Listener) drain() {
+	var timeout time.Duration
+	streams := l.currentStreams()
+	for _, s := range streams {
+		if d := s.request.Load().DrainTimeout; d > timeout {
+			timeout = d
+		}
+	}
 
-			// Close our websocket connection
-			err = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-			if err != nil {
-				log.WithError(err).Errorln("error during closing websocket")
-				return
+	if timeout <= 0 {
+		timeout = defaultDrainTimeout
+	}
+
+	l.draining.Store(true)
+
+	drained := make(chan struct{})
+	go func() {
+		// the write lock is only taken to wait for the handlers holding a
+		// read lock, new events aren't handled once draining is set
+		l.inflight.Lock()
+		l.inflight.Unlock()
+
+		// an event held back for reordering is in-flight as well
+		for _, s := range streams {
+			chaos := s.request.Load().Chaos
+			if chaos == nil {
+				continue
 			}
 
-			select {
-			case <-l.done:
-				log.Println("Receiver Channel Closed! Exiting....")
-			case <-time.After(time.Duration(1) * time.Second):
-				log.Println("Timeout in closing receiving channel. Exiting....")
+			if event := chaos.Release(); event != nil {
+				l.handle(s, event)
 			}
-			return
 		}
+
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		log.Println("All in-flight events delivered")
+	case <-time.After(timeout):
+		log.Warnf("Timed out after %v waiting for in-flight events, they will be redelivered", timeout)
 	}
 }
 
-func (l *Listener) HandleMessage(connection *websocket.Conn, url string) {
-	defer close(l.done)
+// closeAll closes the connections of every stream.
+func (l *Listener) closeAll() {
+	var wg sync.WaitGroup
+	for _, s := range l.currentStreams() {
+		wg.Add(1)
+		go func(s *stream) {
+			defer wg.Done()
+			l.close(s)
+		}(s)
+	}
+	wg.Wait()
+}
+
+func (l *Listener) close(s *stream) {
+	defer s.conn.Close()
+	defer l.setConnected(s, false)
+
+	// Send a message to set the device to offline
+	err := s.writeMessage(websocket.TextMessage, []byte("disconnect"))
+	if err != nil {
+		s.log.WithError(err).Errorln("error during closing websocket")
+		return
+	}
+
+	// Close our websocket connection
+	err = s.writeMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if err != nil {
+		s.log.WithError(err).Errorln("error during closing websocket")
+		return
+	}
+
+	select {
+	case <-s.done:
+		s.log.Println("Receiver Channel Closed! Exiting....")
+	case <-time.After(time.Duration(1) * time.Second):
+		s.log.Println("Timeout in closing receiving channel. Exiting....")
+	}
+}
+
+func (l *Listener) HandleMessage(s *stream) {
+	defer close(s.done)
+	defer l.setConnected(s, false)
 	for {
-		_, msg, err := connection.ReadMessage()
+		event, err := l.read(s)
 		if err != nil {
-			if !websocket.IsUnexpectedCloseError(err,
+			if websocket.IsUnexpectedCloseError(err,
 				websocket.CloseNormalClosure,
 				websocket.CloseGoingAway,
 				websocket.CloseAbnormalClosure) {
-				return
+				s.log.Error("an error occurred in the receive handler:", err)
 			}
 
-			log.Error("an error occurred in the receive handler:", err)
+			// a stream replaced by a reload is closed on purpose, any other
+			// closed connection ends the session, it's a no-op when the
+			// session is already ending
+			if !s.retired.Load() {
+				l.finish(fmt.Errorf("the connection for %s was closed: %v", s.request.Load().streamName(), err))
+			}
 			return
 		}
 
-		var event CLIEvent
-		err = json.Unmarshal(msg, &event)
-		if err != nil {
-			log.Error("an error occurred in unmarshalling json:", err)
+		if event == nil {
 			continue
 		}
 
-		// send request to the recipient
-		d, err := net.NewDispatcher(time.Second*10, "")
-		if err != nil {
-			log.Error("an error occurred while forwarding the event", err)
+		if l.enqueue(s, event) {
 			continue
 		}
 
-		res, err := d.ForwardCliEvent(url, http.MethodPost, event.Data, event.Headers)
-		if err != nil {
-			log.Error("an error occurred while forwarding the event", err)
-			continue
+		l.process(s, event)
+	}
+}
+
+// process handles an event read from the stream unless the session is
+// shutting down.
+func (l *Listener) process(s *stream, event *CLIEvent) {
+	if !l.begin() {
+		l.skip(s, event, "the listener is shutting down")
+		return
+	}
+	defer l.inflight.RUnlock()
+
+	s.busy.Lock()
+	defer s.busy.Unlock()
+
+	if s.retired.Load() {
+		l.skip(s, event, "the listener is shutting down")
+		return
+	}
+
+	l.receive(s, event)
+}
+
+// begin marks an event as in-flight, the caller must release inflight's read
+// lock once it was handled. It reports false when the session is draining.
+func (l *Listener) begin() bool {
+	// a drain waiting for the write lock blocks new read locks, so checking
+	// first keeps the stream reading (and answering pongs) while it drains
+	if l.draining.Load() {
+		return false
+	}
+
+	l.inflight.RLock()
+	if l.draining.Load() {
+		l.inflight.RUnlock()
+		return false
+	}
+	return true
+}
+
+// skip leaves an event the listener can't handle unacknowledged, so the
+// server delivers it again.
+func (l *Listener) skip(s *stream, event *CLIEvent, reason string) {
+	span := trace.SpanFromContext(event.context())
+	span.SetAttributes(attribute.Bool("convoy.event.skipped", true))
+	span.End()
+	s.log.Printf("skipping event %s, %s, it will be redelivered", event.UID, reason)
+}
+
+// warnUntyped warns, once per stream, that an event without a type can't
+// match what is keyed by event type. Convoy doesn't stream the event type,
+// so it is only known when EventTypeField points at it in the payload.
+func (s *stream) warnUntyped(event *CLIEvent, keyed string) {
+	if event.EventType != "" || s.untyped.Swap(true) {
+		return
+	}
+
+	if field := s.request.Load().EventTypeField; field != "" {
+		s.log.Warnf("event %s has no event type at %s, %s can't match it", event.UID, field, keyed)
+		return
+	}
+
+	s.log.Warnf("event %s has no event type, the server doesn't send it, so %s can't match it. "+
+		"Set --event-type-field to read it from the payload (e.g. data.type)", event.UID, keyed)
+}
+
+// read reads the next event from the websocket, it returns a nil event
+// for a message that isn't a valid event. The event span starts once the
+// message starts arriving, so waiting for the next event isn't part of it,
+// and its receive span covers reading and decoding the message.
+func (l *Listener) read(s *stream) (*CLIEvent, error) {
+	_, r, err := s.conn.NextReader()
+	if err != nil {
+		return nil, err
+	}
+
+	// the event span covers the event from receive to ack, it is ended by handle
+	ctx, span := l.tracer.Start(context.Background(), "convoy.event", trace.WithSpanKind(trace.SpanKindConsumer))
+	_, receiveSpan := l.tracer.Start(ctx, "websocket.receive")
+
+	msg, err := io.ReadAll(r)
+	if err != nil {
+		receiveSpan.RecordError(err)
+		receiveSpan.SetStatus(codes.Error, "read failed")
+		receiveSpan.End()
+		span.End()
+		return nil, err
+	}
+
+	var event CLIEvent
+	err = json.Unmarshal(msg, &event)
+	receiveSpan.End()
+	if err != nil {
+		s.log.Error("an error occurred in unmarshalling json:", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid event")
+		span.End()
+		return nil, nil
+	}
+
+	listenRequest := s.request.Load()
+	if event.EventType == "" && listenRequest.EventTypeField != "" {
+		event.EventType, _ = event.lookup(listenRequest.EventTypeField).(string)
+	}
+
+	event.ctx = ctx
+	span.SetAttributes(
+		attribute.String("convoy.event.uid", event.UID),
+		attribute.String("convoy.event.type", event.EventType),
+		attribute.String("convoy.project.id", listenRequest.ProjectID),
+		attribute.String("convoy.source.name", listenRequest.SourceName),
+	)
+
+	return &event, nil
+}
+
+// receive hands an event read from the websocket to handle.
+func (l *Listener) receive(s *stream, event *CLIEvent) {
+	listenRequest := s.request.Load()
+
+	l.stats.Received(event, listenRequest.streamName())
+	l.metrics.Received()
+
+	if listenRequest.Chaos == nil {
+		l.handle(s, event)
+		return
+	}
+
+	events := listenRequest.Chaos.Reorder(event, func(e *CLIEvent) {
+		if !l.begin() {
+			l.skip(s, e, "the listener is shutting down")
+			l.metrics.Skipped()
+			return
 		}
+		defer l.inflight.RUnlock()
 
-		// set the event delivery status to Success when we successfully forward the event
-		ack := &AckEventDelivery{UID: event.UID}
-		mb, err := json.Marshal(ack)
-		if err != nil {
-			log.Error("an error occurred in marshalling json:", err)
-			continue
+		l.handle(s, e)
+	})
+
+	for _, e := range events {
+		l.handle(s, e)
+	}
+}
+
+func (l *Listener) handle(s *stream, event *CLIEvent) {
+	span := trace.SpanFromContext(event.context())
+	defer span.End()
+
+	listenRequest := s.request.Load()
+	if listenRequest.Filter != nil && len(listenRequest.Filter.EventTypes) > 0 {
+		s.warnUntyped(event, "the events filter")
+	}
+
+	if listenRequest.Filter != nil && !listenRequest.Filter.Allows(event) {
+		span.SetAttributes(attribute.Bool("convoy.event.filtered", true))
+		l.stats.Filtered()
+		l.metrics.Handled()
+		s.log.Printf("skipped event %s filtered out by the session config", event.UID)
+		l.ack(s, event)
+		return
+	}
+
+	delivered := l.deliver(s, event)
+	l.metrics.Handled()
+
+	if !delivered {
+		span.SetStatus(codes.Error, "delivery failed")
+	}
+
+	if listenRequest.Until == nil {
+		return
+	}
+
+	met, err := listenRequest.Until.Observe(event, delivered)
+	if err != nil {
+		l.finish(err)
+		return
+	}
+
+	if met {
+		s.log.Println("until condition met")
+		l.finish(nil)
+	}
+}
+
+// deliver answers the event with a mock response or forwards it to the
+// local target, then acknowledges it when delivery succeeded. It reports
+// whether the target answered with a successful status.
+func (l *Listener) deliver(s *stream, event *CLIEvent) bool {
+	listenRequest := s.request.Load()
+
+	ledger := listenRequest.Ledger
+	if ledger != nil && ledger.Seen(event.UID) {
+		trace.SpanFromContext(event.context()).SetAttributes(attribute.Bool("convoy.event.duplicate", true))
+
+		if !listenRequest.ForceDelivery {
+			// the event reached the target before, so only the ack is missing
+			ledger.Suppress()
+			l.stats.Deduplicated()
+			s.log.Printf("suppressed duplicate event %s", event.UID)
+			l.ack(s, event)
+			return true
 		}
 
-		// write an ack message back to the connection here
-		err = connection.WriteMessage(websocket.TextMessage, mb)
+		s.log.Printf("forcing delivery of duplicate event %s", event.UID)
+	}
+
+	if listenRequest.Mock != nil {
+		if listenRequest.Mock.keyedByType() {
+			s.warnUntyped(event, "mock rules keyed by event type")
+		}
+
+		_, span := l.tracer.Start(event.context(), "mock")
+		res := listenRequest.Mock.Respond(event)
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(res.StatusCode))
+		span.End()
+
+		l.stats.Forwarded("mock", len(event.Data), res.StatusCode, len(res.Body), 0)
+		l.metrics.Forwarded("mock", res.StatusCode, 0)
+		s.log.Printf("mocked event %s with status %d", event.UID, res.StatusCode)
+
+		// only successful mock responses are acknowledged, so the server
+		// retries the rest just like it would for a failing target. Mocked
+		// events never reached the target, so they aren't recorded in the
+		// ledger and a later session still delivers them.
+		if !IsSuccessfulStatus(res.StatusCode) {
+			return false
+		}
+
+		l.ack(s, event)
+		return true
+	}
+
+	// send request to the recipient
+	d := s.dispatcher
+	forwarded, err := l.rewrite(listenRequest, event)
+	if err != nil {
+		l.stats.Failed()
+		l.metrics.Failed()
+		s.log.Error("an error occurred while transforming the event", err)
+		return false
+	}
+
+	var res *net.Response
+	start := time.Now()
+	if listenRequest.Chaos != nil {
+		res, err = listenRequest.Chaos.Forward(event.context(), d, listenRequest.ForwardTo, forwarded)
+	} else {
+		res, err = d.ForwardCliEventWithContext(event.context(), listenRequest.ForwardTo, http.MethodPost, forwarded.Data, forwarded.Headers)
+	}
+
+	if err != nil {
+		l.stats.Failed()
+		l.metrics.Failed()
+		s.log.Error("an error occurred while forwarding the event", err)
+		return false
+	}
+
+	latency := time.Since(start)
+	l.stats.Forwarded(listenRequest.ForwardTo, len(forwarded.Data), res.StatusCode, len(res.Body), latency)
+	l.metrics.Forwarded(listenRequest.ForwardTo, res.StatusCode, latency)
+	l.metrics.Timings(listenRequest.ForwardTo, res.Timings)
+
+	s.log.WithField("event_id", event.UID).Printf("forwarded with status %d in %v", res.StatusCode, res.Timings)
+	if listenRequest.ShowTimings {
+		fmt.Print(res.Timings.Format())
+	}
+
+	delivered := IsSuccessfulStatus(res.StatusCode)
+	if delivered {
+		l.record(s, event)
+	}
+	l.ack(s, event)
+
+	s.log.Println(string(res.Body))
+	return delivered
+}
+
+// rewrite returns the event as it is forwarded, with the listen request's
+// headers and transform applied.
+func (l *Listener) rewrite(listenRequest *ListenRequest, event *CLIEvent) (*CLIEvent, error) {
+	if listenRequest.Transform == nil && len(listenRequest.Headers) == 0 {
+		return event, nil
+	}
+
+	forwarded := *event
+
+	if listenRequest.Transform != nil {
+		_, span := l.tracer.Start(event.context(), "transform")
+		data, err := listenRequest.Transform.Apply(event.Data)
 		if err != nil {
-			log.Error("an error occurred while acknowledging the event", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "transform failed")
+			span.End()
+			return nil, err
+		}
+		span.End()
+		forwarded.Data = data
+	}
+
+	if len(listenRequest.Headers) > 0 {
+		forwarded.Headers = make(map[string][]string, len(event.Headers)+len(listenRequest.Headers))
+		for k, v := range event.Headers {
+			forwarded.Headers[http.CanonicalHeaderKey(k)] = v
 		}
 
-		log.Println(string(res.Body))
+		for k, v := range listenRequest.Headers {
+			forwarded.Headers[http.CanonicalHeaderKey(k)] = []string{v}
+		}
 	}
+
+	return &forwarded, nil
+}
+
+func (l *Listener) record(s *stream, event *CLIEvent) {
+	ledger := s.request.Load().Ledger
+	if ledger == nil {
+		return
+	}
+
+	err := ledger.Record(event.UID)
+	if err != nil {
+		s.log.WithError(err).Errorln("failed to record event in the dedupe ledger")
+	}
+}
+
+// ack sets the event delivery status to Success on the server
+func (l *Listener) ack(s *stream, event *CLIEvent) {
+	_, span := l.tracer.Start(event.context(), "ack")
+	defer span.End()
+
+	ack := &AckEventDelivery{UID: event.UID}
+	mb, err := json.Marshal(ack)
+	if err != nil {
+		s.log.Error("an error occurred in marshalling json:", err)
+		return
+	}
+
+	// write an ack message back to the connection here
+	err = s.writeMessage(websocket.TextMessage, mb)
+	if err != nil {
+		s.log.Error("an error occurred while acknowledging the event", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "ack failed")
+		return
+	}
+
+	l.stats.Acked()
+	l.metrics.Acked()
+}
+
+func (l *Listener) setConnected(s *stream, connected bool) {
+	s.connected.Store(connected)
+	listenRequest := s.request.Load()
+	l.metrics.SetConnected(listenRequest.projectName(), listenRequest.SourceName, connected)
+}
+
+// setRequest makes the stream handle its next events with listenRequest,
+// with a Chaos of its own when faults are injected.
+func (s *stream) setRequest(listenRequest *ListenRequest) {
+	if listenRequest.Chaos != nil {
+		r := *listenRequest
+		r.Chaos = listenRequest.Chaos.ForStream()
+		listenRequest = &r
+	}
+
+	s.request.Store(listenRequest)
+}
+
+// writeMessage serializes writes to the connection, gorilla/websocket
+// supports only one concurrent writer.
+func (s *stream) writeMessage(messageType int, data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	err := s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err != nil {
+		s.log.WithError(err).Errorln("failed to set write deadline")
+	}
+
+	return s.conn.WriteMessage(messageType, data)
 }
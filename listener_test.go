@@ -0,0 +1,165 @@
+package convoy_cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeStreamServer accepts listen connections and passes them to handle.
+func newFakeStreamServer(t *testing.T, handle func(conn *websocket.Conn)) *url.URL {
+	upgrader := websocket.Upgrader{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		handle(conn)
+	}))
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return u
+}
+
+// readUntilClosed keeps the connection open, answering pings, until the cli closes it.
+func readUntilClosed(conn *websocket.Conn) {
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func newTestListener() *Listener {
+	return NewListener(&Config{Profile: &Profile{ActiveApiKey: "key"}})
+}
+
+func listenAsync(l *Listener, r *ListenRequest, host *url.URL) chan error {
+	done := make(chan error, 1)
+	go func() { done <- l.Listen(r, host) }()
+	return done
+}
+
+func waitListen(t *testing.T, done chan error) error {
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("listen did not end")
+		return nil
+	}
+}
+
+func TestListener_ServerClosesConnection(t *testing.T) {
+	host := newFakeStreamServer(t, func(conn *websocket.Conn) {})
+
+	err := waitListen(t, listenAsync(newTestListener(), &ListenRequest{ProjectID: "p1"}, host))
+	require.ErrorContains(t, err, "the connection for p1 was closed")
+}
+
+func TestListener_InterruptedBeforeUntil(t *testing.T) {
+	host := newFakeStreamServer(t, readUntilClosed)
+
+	tests := []struct {
+		name    string
+		until   *Until
+		wantErr string
+	}{
+		{name: "without until"},
+		{name: "until not met", until: &Until{Count: 2}, wantErr: "interrupted by interrupt waiting for 2 events, got 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestListener()
+			done := listenAsync(l, &ListenRequest{ProjectID: "p1", Until: tt.until, DrainTimeout: time.Second}, host)
+
+			require.Eventually(t, l.ready.Load, 5*time.Second, 10*time.Millisecond)
+			l.interrupt <- os.Interrupt
+
+			err := waitListen(t, done)
+
+			// a signal after the session ended no longer exits the process
+			l.interrupt <- os.Interrupt
+			time.Sleep(50 * time.Millisecond)
+
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestListener_Timeout(t *testing.T) {
+	host := newFakeStreamServer(t, readUntilClosed)
+
+	tests := []struct {
+		name    string
+		until   *Until
+		wantErr string
+	}{
+		{name: "only bounds the session", until: &Until{Timeout: 100 * time.Millisecond}},
+		{name: "until not met", until: &Until{Count: 1, Timeout: 100 * time.Millisecond}, wantErr: "timed out after 100ms waiting for 1 events, got 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := waitListen(t, listenAsync(newTestListener(), &ListenRequest{ProjectID: "p1", Until: tt.until, DrainTimeout: time.Second}, host))
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestListener_DrainReleasesInflight(t *testing.T) {
+	l := newTestListener()
+
+	// an event in-flight when the session starts draining
+	require.True(t, l.begin())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l.inflight.RUnlock()
+	}()
+
+	l.drain()
+
+	// events received while draining are skipped without blocking the reader
+	require.False(t, l.begin())
+	require.True(t, l.inflight.TryLock())
+}
+
+func TestListener_EventTypeField(t *testing.T) {
+	// convoy streams events without their type
+	host := newFakeStreamServer(t, func(conn *websocket.Conn) {
+		err := conn.WriteMessage(websocket.TextMessage, []byte(`{"uid": "e1", "headers": {}, "data": {"type": "invoice.failed"}}`))
+		require.NoError(t, err)
+		readUntilClosed(conn)
+	})
+
+	mock := NewMockConfig(http.StatusInternalServerError, 1)
+	mock.Rules["invoice.failed"] = MockRule{Status: http.StatusAccepted, Rate: 1}
+
+	err := waitListen(t, listenAsync(newTestListener(), &ListenRequest{
+		ProjectID:      "p1",
+		Mock:           mock,
+		EventTypeField: "data.type",
+		Until:          &Until{Count: 1},
+	}, host))
+	require.NoError(t, err)
+}
@@ -0,0 +1,128 @@
+package convoy_cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Time to wait for another command to release the config lock.
+const lockTimeout = 10 * time.Second
+
+var errLocked = errors.New("locked")
+
+// fileLocks are the in-process locks of each lock file. The advisory lock
+// only excludes other processes, goroutines of this one wait on these.
+var fileLocks = struct {
+	sync.Mutex
+	paths map[string]*sync.Mutex
+}{paths: map[string]*sync.Mutex{}}
+
+// LockConfig takes the advisory lock of the config file, commands that read,
+// change and write the config hold it so concurrent commands don't lose
+// each other's changes. The returned function releases it. The lock isn't
+// reentrant, configs loaded while it is held need ConfigOverrides.Locked set
+// so they are written without taking it again.
+func LockConfig(o ConfigOverrides) (func(), error) {
+	o, err := o.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	return lockFile(o.Path)
+}
+
+// lockFile takes an exclusive lock on path.lock, waiting up to lockTimeout
+// for other goroutines and processes to release it.
+func lockFile(path string) (func(), error) {
+	path += ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	fileLocks.Lock()
+	mu, ok := fileLocks.paths[path]
+	if !ok {
+		mu = &sync.Mutex{}
+		fileLocks.paths[path] = mu
+	}
+	fileLocks.Unlock()
+
+	for !mu.TryLock() {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another convoy-cli command", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	f, err := flockFile(path, deadline)
+	if err != nil {
+		mu.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		// closing the file releases the lock
+		f.Close()
+		mu.Unlock()
+	}, nil
+}
+
+// flockFile opens path and takes its advisory lock, other processes hold it
+// until deadline at most.
+func flockFile(path string, deadline time.Time) (*os.File, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		err = tryLock(f)
+		if err == nil {
+			return f, nil
+		}
+
+		if !errors.Is(err, errLocked) || time.Now().After(deadline) {
+			f.Close()
+			if errors.Is(err, errLocked) {
+				return nil, fmt.Errorf("%s is locked by another convoy-cli command", path)
+			}
+			return nil, err
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it over path, so readers see either the old or the new file, never a
+// partial one.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,62 @@
+package convoy_cli
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+
+	unlock, err := lockFile(path)
+	require.NoError(t, err)
+
+	// another goroutine waits for the lock
+	var released atomic.Bool
+	locked := make(chan struct{})
+	go func() {
+		unlock, err := lockFile(path)
+		require.NoError(t, err)
+		require.True(t, released.Load())
+		unlock()
+		close(locked)
+	}()
+
+	// another open file, as in another process, can't take it
+	f, err := os.OpenFile(path+".lock", os.O_RDWR, 0600)
+	require.NoError(t, err)
+	defer f.Close()
+	require.ErrorIs(t, tryLock(f), errLocked)
+
+	time.Sleep(50 * time.Millisecond)
+	released.Store(true)
+	unlock()
+	<-locked
+	require.NoError(t, tryLock(f))
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+
+	require.NoError(t, writeFileAtomic(path, []byte("a"), 0600))
+	require.NoError(t, writeFileAtomic(path, []byte("b"), 0600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "b", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
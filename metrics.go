@@ -0,0 +1,301 @@
+package convoy_cli
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	cliNet "github.com/frain-dev/convoy-cli/net"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	metricsNamespace = "convoy_cli"
+
+	// Time allowed to dial the forward target when checking readiness.
+	targetDialTimeout = 2 * time.Second
+)
+
+// Metrics holds the prometheus collectors for a listen session. All methods
+// are safe to call on a nil *Metrics, so callers don't need to check whether
+// metrics are enabled.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	connected     *prometheus.GaugeVec
+	reconnects    prometheus.Counter
+	received      prometheus.Counter
+	forwarded     *prometheus.CounterVec
+	acked         prometheus.Counter
+	failed        prometheus.Counter
+	skipped       prometheus.Counter
+	latency       *prometheus.HistogramVec
+	phases        *prometheus.HistogramVec
+	queueDepth    prometheus.Gauge
+	lastEventTime prometheus.Gauge
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		connected: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "websocket_connected",
+			Help:      "Whether the websocket connection to the server is open (1) or not (0).",
+		}, []string{"project", "source"}),
+		reconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "websocket_reconnects_total",
+			Help:      "Number of times a websocket connection was re-established, e.g. by a config reload.",
+		}),
+		received: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "events_received_total",
+			Help:      "Number of events received from the server.",
+		}),
+		forwarded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "events_forwarded_total",
+			Help:      "Number of events forwarded to the target, by response status code.",
+		}, []string{"target", "status_code"}),
+		acked: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "events_acked_total",
+			Help:      "Number of events acknowledged to the server.",
+		}),
+		failed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "events_failed_total",
+			Help:      "Number of events that could not be delivered to the target.",
+		}),
+		skipped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "events_skipped_total",
+			Help:      "Number of received events skipped, e.g. on shutdown, for the server to redeliver.",
+		}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "forward_duration_seconds",
+			Help:      "Time taken to forward an event to the target.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"target"}),
+		phases: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "forward_phase_duration_seconds",
+			Help:      "Time taken by each phase (dns, connect, tls, server, transfer) of forwarding an event.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"target", "phase"}),
+		queueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "queue_depth",
+			Help:      "Number of received events not yet delivered.",
+		}),
+		lastEventTime: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "last_event_timestamp_seconds",
+			Help:      "Unix time the last event was received.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.connected, m.reconnects, m.received, m.forwarded, m.acked,
+		m.failed, m.skipped, m.latency, m.phases, m.queueDepth, m.lastEventTime,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+func (m *Metrics) SetConnected(project, source string, connected bool) {
+	if m == nil {
+		return
+	}
+
+	if connected {
+		m.connected.WithLabelValues(project, source).Set(1)
+		return
+	}
+	m.connected.WithLabelValues(project, source).Set(0)
+}
+
+func (m *Metrics) Reconnected() {
+	if m == nil {
+		return
+	}
+	m.reconnects.Inc()
+}
+
+func (m *Metrics) Received() {
+	if m == nil {
+		return
+	}
+	m.received.Inc()
+	m.queueDepth.Inc()
+	m.lastEventTime.SetToCurrentTime()
+}
+
+// Handled marks a received event as no longer queued.
+func (m *Metrics) Handled() {
+	if m == nil {
+		return
+	}
+	m.queueDepth.Dec()
+}
+
+// Skipped marks a received event as no longer queued without it being handled.
+func (m *Metrics) Skipped() {
+	if m == nil {
+		return
+	}
+	m.skipped.Inc()
+	m.queueDepth.Dec()
+}
+
+func (m *Metrics) Forwarded(target string, statusCode int, latency time.Duration) {
+	if m == nil {
+		return
+	}
+	m.forwarded.WithLabelValues(target, strconv.Itoa(statusCode)).Inc()
+	m.latency.WithLabelValues(target).Observe(latency.Seconds())
+	if !IsSuccessfulStatus(statusCode) {
+		m.failed.Inc()
+	}
+}
+
+// Timings records the duration of each phase of a forwarded request,
+// phases skipped on a reused connection are not observed.
+func (m *Metrics) Timings(target string, timings cliNet.Timings) {
+	if m == nil {
+		return
+	}
+
+	for _, p := range timings.Phases() {
+		if p.Duration == 0 {
+			continue
+		}
+		m.phases.WithLabelValues(target, p.Name).Observe(p.Duration.Seconds())
+	}
+}
+
+func (m *Metrics) Failed() {
+	if m == nil {
+		return
+	}
+	m.failed.Inc()
+}
+
+func (m *Metrics) Acked() {
+	if m == nil {
+		return
+	}
+	m.acked.Inc()
+}
+
+// ServeMetrics exposes prometheus metrics on /metrics, and liveness and
+// readiness checks on /healthz and /readyz at addr. /healthz fails while
+// any websocket is disconnected, /readyz also fails while a forward
+// target is unreachable.
+func (l *Listener) ServeMetrics(addr string) error {
+	l.metrics = NewMetrics()
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := http.Serve(ln, l.metricsHandler())
+		if err != nil {
+			log.WithError(err).Errorln("metrics server stopped")
+		}
+	}()
+
+	log.Printf("serving metrics on %s", ln.Addr())
+	return nil
+}
+
+func (l *Listener) metricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(l.metrics.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if err := l.checkConnected(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := l.checkConnected(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		if err := l.checkTarget(); err != nil {
+			http.Error(w, "target unreachable: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	return mux
+}
+
+// checkConnected fails unless the websocket of every stream is open.
+func (l *Listener) checkConnected() error {
+	streams := l.currentStreams()
+	if len(streams) == 0 {
+		return errors.New("websocket disconnected")
+	}
+
+	for _, s := range streams {
+		if !s.connected.Load() {
+			return fmt.Errorf("websocket disconnected for %s", s.request.Load().streamName())
+		}
+	}
+	return nil
+}
+
+// checkTarget dials the forward target of every stream of the session.
+func (l *Listener) checkTarget() error {
+	for _, s := range l.currentStreams() {
+		listenRequest := s.request.Load()
+		if listenRequest.Mock != nil {
+			continue
+		}
+
+		if err := dialTarget(listenRequest.ForwardTo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dialTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	conn, err := net.DialTimeout("tcp", host, targetDialTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
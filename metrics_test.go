@@ -0,0 +1,96 @@
+package convoy_cli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, h http.Handler, path string) (int, string) {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+	return w.Code, string(body)
+}
+
+func TestListener_HealthChecks(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	l := newTestListener()
+	l.metrics = NewMetrics()
+	h := l.metricsHandler()
+
+	code, body := get(t, h, "/healthz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Contains(t, body, "websocket disconnected")
+
+	s := &stream{}
+	s.request.Store(&ListenRequest{ProjectID: "p1", ForwardTo: target.URL})
+	l.streams = []*stream{s}
+
+	code, body = get(t, h, "/healthz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Contains(t, body, "websocket disconnected for p1")
+
+	l.setConnected(s, true)
+	code, _ = get(t, h, "/healthz")
+	require.Equal(t, http.StatusOK, code)
+
+	code, _ = get(t, h, "/readyz")
+	require.Equal(t, http.StatusOK, code)
+
+	s.request.Store(&ListenRequest{ProjectID: "p1", ForwardTo: "http://127.0.0.1:1"})
+	code, body = get(t, h, "/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Contains(t, body, "target unreachable")
+
+	// a mocked session has no target to reach
+	s.request.Store(&ListenRequest{ProjectID: "p1", Mock: NewMockConfig(200, 1)})
+	code, _ = get(t, h, "/readyz")
+	require.Equal(t, http.StatusOK, code)
+
+	code, body = get(t, h, "/metrics")
+	require.Equal(t, http.StatusOK, code)
+	require.Contains(t, body, `convoy_cli_websocket_connected{project="p1",source=""} 1`)
+}
+
+func TestListener_ReconnectMetric(t *testing.T) {
+	host := newFakeStreamServer(t, readUntilClosed)
+
+	l := newTestListener()
+	l.c.Host = host.String()
+	l.metrics = NewMetrics()
+	done := listenAsync(l, &ListenRequest{ProjectID: "p1", DrainTimeout: time.Second}, host)
+	require.Eventually(t, l.ready.Load, 5*time.Second, 10*time.Millisecond)
+
+	// a new source needs a connection of its own
+	require.NoError(t, l.Reload(l.c, []*ListenRequest{{ProjectID: "p1", SourceName: "stripe"}}))
+
+	_, body := get(t, l.metricsHandler(), "/metrics")
+	require.Contains(t, body, "convoy_cli_websocket_reconnects_total 1")
+
+	l.finish(nil)
+	require.NoError(t, waitListen(t, done))
+}
+
+func TestMetrics_QueueDepth(t *testing.T) {
+	l := newTestListener()
+	l.metrics = NewMetrics()
+
+	for i := 0; i < 3; i++ {
+		l.metrics.Received()
+	}
+	l.metrics.Handled()
+	l.metrics.Skipped()
+
+	_, body := get(t, l.metricsHandler(), "/metrics")
+	require.Contains(t, body, "convoy_cli_queue_depth 1")
+	require.Contains(t, body, "convoy_cli_events_skipped_total 1")
+}
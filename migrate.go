@@ -0,0 +1,260 @@
+package convoy_cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/frain-dev/convoy-cli/util"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigVersion is the version of the config file written by this cli.
+// Older files are upgraded by configMigrations when they are loaded.
+const ConfigVersion = 3
+
+// configMigrations upgrade a parsed config file, the migration at index i
+// upgrades version i+1 to version i+2.
+var configMigrations = []func(doc map[string]interface{}) error{
+	migrateToProfiles,
+	migrateToSecretStore,
+}
+
+// ConfigMigration is the upgrade of a config file to ConfigVersion.
+type ConfigMigration struct {
+	Path string
+	From int
+	To   int
+
+	// Before and After are the file before and after the upgrade, the api
+	// keys in Before are masked
+	Before []byte
+	After  []byte
+
+	// ApiKeys are the profiles whose plaintext api key moves to the secret store
+	ApiKeys []string
+
+	// original is the file as it is, migrated is the upgraded file, both
+	// with the api keys still in them
+	original []byte
+	migrated []byte
+}
+
+// Backup is where the file is kept before it is upgraded.
+func (m *ConfigMigration) Backup() string {
+	return fmt.Sprintf("%s.v%d%s", m.Path, m.From, backupSuffix)
+}
+
+// MigrateConfig upgrades the config file to ConfigVersion, or only returns
+// what would change when dryRun is set. It returns nil when the file is up
+// to date.
+func MigrateConfig(o ConfigOverrides, dryRun bool) (*ConfigMigration, error) {
+	o, err := o.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockFile(o.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{path: o.Path, locked: true}
+	m, err := c.planMigration(data)
+	if err != nil || m == nil || dryRun {
+		return m, err
+	}
+
+	return m, c.load()
+}
+
+// planMigration returns the upgrade of the config file data, nil when it
+// is up to date.
+func (c *Config) planMigration(data []byte) (*ConfigMigration, error) {
+	doc := map[string]interface{}{}
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, c.corrupt(err)
+	}
+
+	version, err := configVersion(doc)
+	if err != nil {
+		return nil, c.corrupt(err)
+	}
+
+	if version > ConfigVersion {
+		return nil, fmt.Errorf("config file %s has version %d, this convoy-cli only supports up to version %d\nUpgrade convoy-cli to use it", c.path, version, ConfigVersion)
+	}
+
+	if version == ConfigVersion {
+		return nil, nil
+	}
+
+	for _, migrate := range configMigrations[version-1:] {
+		err = migrate(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate config file %s: %v", c.path, err)
+		}
+	}
+	doc["version"] = ConfigVersion
+
+	m := &ConfigMigration{Path: c.path, From: version, To: ConfigVersion, original: data}
+	m.Before, err = maskApiKeys(data)
+	if err != nil {
+		return nil, c.corrupt(err)
+	}
+
+	m.migrated, err = yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	migrated := &Config{}
+	err = yaml.Unmarshal(m.migrated, migrated)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range migrated.ProfileNames() {
+		if !util.IsStringEmpty(migrated.Profiles[name].ActiveApiKey) {
+			m.ApiKeys = append(m.ApiKeys, name)
+		}
+	}
+
+	m.After, err = migrated.marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// configVersion returns the version of a parsed config file. Files written
+// before versions existed are version 2 when they have profiles, else 1.
+func configVersion(doc map[string]interface{}) (int, error) {
+	v, ok := doc["version"]
+	if !ok {
+		if _, ok = doc["profiles"]; ok {
+			return 2, nil
+		}
+		return 1, nil
+	}
+
+	version, ok := v.(int)
+	if !ok || version < 1 {
+		return 0, fmt.Errorf("invalid version %v", v)
+	}
+	return version, nil
+}
+
+// migrateToProfiles moves the host, key and projects of version 1, which
+// had a single host, to a default profile.
+func migrateToProfiles(doc map[string]interface{}) error {
+	profile := map[string]interface{}{}
+	for _, key := range []string{"host", "active_api_key", "active_project_id", "projects"} {
+		if v, ok := doc[key]; ok {
+			profile[key] = v
+			delete(doc, key)
+		}
+	}
+
+	if host, _ := profile["host"].(string); util.IsStringEmpty(host) {
+		return nil
+	}
+
+	doc["profiles"] = map[string]interface{}{DefaultProfileName: profile}
+	doc["active_profile"] = DefaultProfileName
+	return nil
+}
+
+// migrateToSecretStore leaves the file as is: the plaintext api keys of
+// version 2 are moved to the secret store when the upgraded file is written.
+func migrateToSecretStore(doc map[string]interface{}) error {
+	profiles, _ := doc["profiles"].(map[string]interface{})
+	for name, p := range profiles {
+		if _, ok := p.(map[string]interface{}); !ok {
+			return fmt.Errorf("profile %s is not a map", name)
+		}
+	}
+
+	return nil
+}
+
+// withoutApiKeys returns the config file data without its plaintext api
+// keys, so the backup of a migrated file doesn't keep them readable.
+func withoutApiKeys(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	removeKey(&doc, "active_api_key")
+	return yaml.Marshal(&doc)
+}
+
+// maskApiKeys returns the config file data with its plaintext api keys
+// masked, so it can be shown e.g. in the diff of a migration.
+func maskApiKeys(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// the keys are replaced where they are in the file, so the rest of it
+	// keeps its formatting
+	lines := strings.Split(string(data), "\n")
+	for _, n := range keyValues(&doc, "active_api_key") {
+		if n.Kind != yaml.ScalarNode || util.IsStringEmpty(n.Value) || n.Line > len(lines) {
+			continue
+		}
+
+		line := lines[n.Line-1]
+		if n.Column-1 > len(line) {
+			continue
+		}
+		lines[n.Line-1] = line[:n.Column-1] + strings.Replace(line[n.Column-1:], n.Value, MaskSecret(n.Value), 1)
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// keyValues returns the value nodes of key in every mapping of n.
+func keyValues(n *yaml.Node, key string) []*yaml.Node {
+	var values []*yaml.Node
+	if n.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				values = append(values, n.Content[i+1])
+			}
+		}
+	}
+
+	for _, child := range n.Content {
+		values = append(values, keyValues(child, key)...)
+	}
+	return values
+}
+
+func removeKey(n *yaml.Node, key string) {
+	if n.Kind == yaml.MappingNode {
+		content := n.Content[:0]
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value != key {
+				content = append(content, n.Content[i], n.Content[i+1])
+			}
+		}
+		n.Content = content
+	}
+
+	for _, child := range n.Content {
+		removeKey(child, key)
+	}
+}
@@ -0,0 +1,125 @@
+package convoy_cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_Migrations(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantVersion int
+		wantHost    string
+		wantErr     string
+	}{
+		{
+			name: "version 1",
+			data: `
+host: https://cli.getconvoy.io
+active_api_key: key
+active_project_id: p1
+`,
+			wantVersion: 1,
+			wantHost:    "https://cli.getconvoy.io",
+		},
+		{
+			name: "version 2",
+			data: `
+active_profile: cloud
+profiles:
+  cloud:
+    host: https://cli.getconvoy.io
+    active_api_key: key
+`,
+			wantVersion: 2,
+			wantHost:    "https://cli.getconvoy.io",
+		},
+		{
+			name:    "newer version",
+			data:    "version: 99\n",
+			wantErr: "only supports up to version 3",
+		},
+		{
+			name:    "invalid version",
+			data:    "version: latest\n",
+			wantErr: "is corrupt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, tt.data)
+
+			c, err := LoadConfig(ConfigOverrides{})
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, ConfigVersion, c.Version)
+			require.Equal(t, tt.wantHost, c.Host)
+			require.Equal(t, "key", c.ActiveApiKey)
+
+			// the previous version is kept without the api key
+			m := &ConfigMigration{Path: path, From: tt.wantVersion}
+			backup, err := os.ReadFile(m.Backup())
+			require.NoError(t, err)
+			require.Contains(t, string(backup), "host: https://cli.getconvoy.io")
+			require.NotContains(t, string(backup), "active_api_key")
+
+			// an up to date file isn't migrated again
+			m, err = MigrateConfig(ConfigOverrides{}, false)
+			require.NoError(t, err)
+			require.Nil(t, m)
+		})
+	}
+}
+
+func TestMigrateConfig_DryRun(t *testing.T) {
+	data := `
+host: https://cli.getconvoy.io
+active_api_key: key
+`
+	path := writeConfigFile(t, data)
+
+	m, err := MigrateConfig(ConfigOverrides{}, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, m.From)
+	require.Equal(t, ConfigVersion, m.To)
+	require.Equal(t, []string{DefaultProfileName}, m.ApiKeys)
+	require.Contains(t, string(m.After), "active_profile: default")
+	require.NotContains(t, string(m.After), "active_api_key")
+
+	// the diff shows the file as it is, with the api key masked
+	require.Equal(t, "\nhost: https://cli.getconvoy.io\nactive_api_key: "+maskedSecret+"\n", string(m.Before))
+
+	// nothing was written
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, data, string(after))
+	require.NoFileExists(t, m.Backup())
+}
+
+func TestMaskApiKeys(t *testing.T) {
+	data := `# keys of every profile
+profiles:
+  cloud:
+    host: https://cli.getconvoy.io
+    active_api_key: "CO.abcdefghijkl"
+  local: {host: "http://localhost:5005", active_api_key: CO.mnopqrstuvwx}
+`
+
+	masked, err := maskApiKeys([]byte(data))
+	require.NoError(t, err)
+	require.Equal(t, `# keys of every profile
+profiles:
+  cloud:
+    host: https://cli.getconvoy.io
+    active_api_key: "********ijkl"
+  local: {host: "http://localhost:5005", active_api_key: ********uvwx}
+`, string(masked))
+}
@@ -0,0 +1,186 @@
+package convoy_cli
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/frain-dev/convoy-cli/net"
+	"github.com/frain-dev/convoy-cli/util"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultMockEventType = "*"
+
+// MockRule describes how the listener answers an event on its own instead of
+// forwarding it to a local target.
+type MockRule struct {
+	// Status is the status code returned for the fraction of events selected by Rate.
+	Status int `yaml:"status"`
+
+	// Rate is the fraction (0-1) of events answered with Status, the remaining
+	// events are answered with 200. A zero rate means no event, a rule
+	// without a rate in the rules file applies to every event.
+	Rate float64 `yaml:"rate"`
+
+	Body    string            `yaml:"body"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// MockConfig holds the default mock rule and the rules keyed by event type.
+// Convoy doesn't stream the event type, rules keyed by it only match when
+// the listener reads the type from the payload, see ListenRequest.EventTypeField.
+type MockConfig struct {
+	Default MockRule            `yaml:"default"`
+	Rules   map[string]MockRule `yaml:"rules"`
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func NewMockConfig(status int, rate float64) *MockConfig {
+	return &MockConfig{
+		Default: MockRule{Status: status, Rate: rate},
+		Rules:   map[string]MockRule{},
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// LoadMockRules reads a yaml rules file into m, rules in the file
+// override rules with the same event type already present in m.
+func (m *MockConfig) LoadMockRules(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var f MockConfig
+	err = yaml.Unmarshal(data, &f)
+	if err != nil {
+		return fmt.Errorf("failed to parse mock rules file: %v", err)
+	}
+
+	if f.Default.Status != 0 {
+		m.Default = f.Default
+	}
+
+	for eventType, rule := range f.Rules {
+		m.Rules[eventType] = rule
+	}
+
+	return m.Validate()
+}
+
+func (m *MockConfig) Validate() error {
+	if err := m.Default.validate(); err != nil {
+		return fmt.Errorf("invalid default mock rule: %v", err)
+	}
+
+	for eventType, rule := range m.Rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("invalid mock rule for %s: %v", eventType, err)
+		}
+	}
+
+	return nil
+}
+
+// Rule returns the rule that applies to the given event type, falling back
+// to the "*" rule and then the default rule.
+func (m *MockConfig) Rule(eventType string) MockRule {
+	if !util.IsStringEmpty(eventType) {
+		if r, ok := m.Rules[eventType]; ok {
+			return r
+		}
+	}
+
+	if r, ok := m.Rules[defaultMockEventType]; ok {
+		return r
+	}
+
+	return m.Default
+}
+
+// keyedByType reports whether some rules only apply to an event type.
+func (m *MockConfig) keyedByType() bool {
+	for eventType := range m.Rules {
+		if eventType != defaultMockEventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Respond builds the response the listener should act on for the event.
+func (m *MockConfig) Respond(event *CLIEvent) *net.Response {
+	rule := m.Rule(event.EventType)
+
+	status := http.StatusOK
+	if rule.Status != 0 && m.hit(rule.Rate) {
+		status = rule.Status
+	}
+
+	header := http.Header{}
+	for k, v := range rule.Headers {
+		header.Set(k, v)
+	}
+
+	return &net.Response{
+		Status:         fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:     status,
+		Method:         http.MethodPost,
+		URL:            &url.URL{Scheme: "mock"},
+		RequestHeader:  http.Header(event.Headers),
+		ResponseHeader: header,
+		Body:           []byte(rule.Body),
+	}
+}
+
+func (m *MockConfig) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	if rate >= 1 {
+		return true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.rand.Float64() < rate
+}
+
+// UnmarshalYAML defaults the rate of a rule to 1 when the file leaves it out.
+func (r *MockRule) UnmarshalYAML(value *yaml.Node) error {
+	type plain MockRule
+	rule := plain{Rate: 1}
+	if err := value.Decode(&rule); err != nil {
+		return err
+	}
+
+	*r = MockRule(rule)
+	return nil
+}
+
+func (r MockRule) validate() error {
+	if r.Status != 0 && (r.Status < 100 || r.Status > 599) {
+		return fmt.Errorf("status %d is not a valid http status code", r.Status)
+	}
+
+	if r.Rate < 0 || r.Rate > 1 {
+		return errors.New("rate must be between 0 and 1")
+	}
+
+	return nil
+}
+
+// IsSuccessfulStatus reports whether a status code counts as a successful delivery.
+func IsSuccessfulStatus(statusCode int) bool {
+	return statusCode >= 200 && statusCode <= 299
+}
@@ -0,0 +1,79 @@
+package convoy_cli
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockConfig_Respond(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.yml")
+
+	rules := []byte(`
+rules:
+  invoice.paid:
+    status: 500
+    body: '{"error": "boom"}'
+  invoice.created:
+    status: 201
+`)
+	require.NoError(t, os.WriteFile(path, rules, 0600))
+
+	m := NewMockConfig(404, 1)
+	require.NoError(t, m.LoadMockRules(path))
+
+	tests := []struct {
+		eventType  string
+		statusCode int
+		body       string
+	}{
+		{"invoice.paid", http.StatusInternalServerError, `{"error": "boom"}`},
+		{"invoice.created", http.StatusCreated, ""},
+		{"invoice.deleted", http.StatusNotFound, ""},
+		{"", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			res := m.Respond(&CLIEvent{UID: "1", EventType: tt.eventType})
+			require.Equal(t, tt.statusCode, res.StatusCode)
+			require.Equal(t, tt.body, string(res.Body))
+		})
+	}
+}
+
+func TestMockConfig_Rate(t *testing.T) {
+	never := NewMockConfig(http.StatusInternalServerError, 0)
+	always := NewMockConfig(http.StatusInternalServerError, 1)
+
+	for i := 0; i < 20; i++ {
+		require.Equal(t, http.StatusOK, never.Respond(&CLIEvent{UID: "1"}).StatusCode)
+		require.Equal(t, http.StatusInternalServerError, always.Respond(&CLIEvent{UID: "1"}).StatusCode)
+	}
+
+	path := filepath.Join(t.TempDir(), "rules.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+rules:
+  invoice.paid:
+    status: 500
+  invoice.created:
+    status: 500
+    rate: 0
+`), 0600))
+
+	m := NewMockConfig(http.StatusInternalServerError, 0)
+	require.NoError(t, m.LoadMockRules(path))
+	require.Equal(t, 1.0, m.Rule("invoice.paid").Rate)
+	require.Equal(t, http.StatusInternalServerError, m.Respond(&CLIEvent{UID: "1", EventType: "invoice.paid"}).StatusCode)
+	require.Equal(t, http.StatusOK, m.Respond(&CLIEvent{UID: "1", EventType: "invoice.created"}).StatusCode)
+}
+
+func TestMockConfig_Validate(t *testing.T) {
+	require.Error(t, NewMockConfig(42, 1).Validate())
+	require.Error(t, NewMockConfig(500, 1.5).Validate())
+	require.NoError(t, NewMockConfig(500, 0.3).Validate())
+}
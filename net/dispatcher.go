@@ -2,6 +2,7 @@ package net
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,10 +13,17 @@ import (
 
 	"github.com/frain-dev/convoy/pkg/httpheader"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const MaxRequestSize = 50 * 1024 //50KB
 
+const tracerName = "github.com/frain-dev/convoy-cli/net"
+
 type Dispatcher struct {
 	client *http.Client
 }
@@ -35,6 +43,11 @@ func NewDispatcher(timeout time.Duration, httpProxy string) (*Dispatcher, error)
 	return d, nil
 }
 
+// Timeout is the time limit of the requests of the dispatcher.
+func (d *Dispatcher) Timeout() time.Duration {
+	return d.client.Timeout
+}
+
 func (d *Dispatcher) SendCliRequest(url string, method string, apiKey string, jsonData json.RawMessage) (*Response, error) {
 	r := &Response{}
 
@@ -46,7 +59,9 @@ func (d *Dispatcher) SendCliRequest(url string, method string, apiKey string, js
 
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("User-Agent", defaultUserAgent())
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	if len(apiKey) > 0 {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	}
 
 	r.RequestHeader = req.Header
 	r.URL = req.URL
@@ -58,9 +73,15 @@ func (d *Dispatcher) SendCliRequest(url string, method string, apiKey string, js
 }
 
 func (d *Dispatcher) ForwardCliEvent(url string, method string, jsonData json.RawMessage, headers httpheader.HTTPHeader) (*Response, error) {
+	return d.ForwardCliEventWithContext(context.Background(), url, method, jsonData, headers)
+}
+
+// ForwardCliEventWithContext is ForwardCliEvent with a context, the span in ctx
+// becomes the parent of the request span and is propagated to the target.
+func (d *Dispatcher) ForwardCliEventWithContext(ctx context.Context, url string, method string, jsonData json.RawMessage, headers httpheader.HTTPHeader) (*Response, error) {
 	r := &Response{}
 
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		log.WithError(err).Error("error occurred while creating request")
 		return r, err
@@ -93,6 +114,7 @@ type Response struct {
 	Body           []byte
 	IP             string
 	Error          string
+	Timings        Timings
 }
 
 func updateDispatchHeaders(r *Response, res *http.Response) {
@@ -111,23 +133,46 @@ func defaultUserAgent() string {
 }
 
 func (d *Dispatcher) do(req *http.Request, res *Response, maxResponseSize int64) error {
-	trace := &httptrace.ClientTrace{
+	t := &timer{start: time.Now()}
+	clientTrace := &httptrace.ClientTrace{
 		GotConn: func(connInfo httptrace.GotConnInfo) {
+			t.gotConn(connInfo.Reused)
 			res.IP = connInfo.Conn.RemoteAddr().String()
 			log.Infof("IP address resolved to: %s", connInfo.Conn.RemoteAddr())
 		},
 	}
+	t.trace(clientTrace)
+
+	ctx, span := otel.Tracer(tracerName).Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(req.Method),
+			semconv.HTTPURLKey.String(req.URL.String()),
+		))
+	defer span.End()
+
+	// the request headers are set before the span exists, so the traceparent
+	// header is injected here to join the receiver's trace to ours
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
-	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	req = req.WithContext(httptrace.WithClientTrace(ctx, clientTrace))
 
 	response, err := d.client.Do(req)
 	if err != nil {
 		log.WithError(err).Error("error sending request to API endpoint")
 		res.Error = err.Error()
+		res.Timings = t.timings(time.Now())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return err
 	}
 	updateDispatchHeaders(res, response)
 
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(response.StatusCode))
+	if response.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, response.Status)
+	}
+
 	// io.LimitReader will attempt to read from response.Body until maxResponseSize is reached.
 	// if response.Body's length is less than maxResponseSize. body.Read will return io.EOF,
 	// if it is greater than maxResponseSize. body.Read will return io.EOF,
@@ -136,6 +181,7 @@ func (d *Dispatcher) do(req *http.Request, res *Response, maxResponseSize int64)
 	body := io.LimitReader(response.Body, maxResponseSize)
 	buf, err := io.ReadAll(body)
 	res.Body = buf
+	res.Timings = t.timings(time.Now())
 
 	if err != nil {
 		log.WithError(err).Error("couldn't parse response body")
@@ -0,0 +1,154 @@
+package net
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timings is the breakdown of the time taken by a request, phases that did
+// not happen (e.g. dns and connect on a reused connection) are zero.
+type Timings struct {
+	DNSLookup        time.Duration
+	TCPConnection    time.Duration
+	TLSHandshake     time.Duration
+	ServerProcessing time.Duration
+	TimeToFirstByte  time.Duration
+	ContentTransfer  time.Duration
+	Total            time.Duration
+	ConnReused       bool
+}
+
+// Phases returns the timings keyed by phase name, in the order they happen.
+func (t Timings) Phases() []Phase {
+	return []Phase{
+		{"dns", t.DNSLookup},
+		{"connect", t.TCPConnection},
+		{"tls", t.TLSHandshake},
+		{"server", t.ServerProcessing},
+		{"transfer", t.ContentTransfer},
+	}
+}
+
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// String returns the timings on a single line, e.g. for logs.
+func (t Timings) String() string {
+	b := &strings.Builder{}
+	for _, p := range t.Phases() {
+		fmt.Fprintf(b, "%s=%v ", p.Name, p.Duration.Round(time.Microsecond))
+	}
+	fmt.Fprintf(b, "ttfb=%v total=%v", t.TimeToFirstByte.Round(time.Microsecond), t.Total.Round(time.Microsecond))
+	return b.String()
+}
+
+// Format renders the timings in the style of httpstat.
+func (t Timings) Format() string {
+	cell := func(d time.Duration, width int) string {
+		v := d.Round(time.Millisecond).String()
+		if len(v) >= width {
+			return v
+		}
+		left := (width - len(v)) / 2
+		return strings.Repeat(" ", left) + v + strings.Repeat(" ", width-len(v)-left)
+	}
+
+	b := &strings.Builder{}
+	b.WriteString("  DNS Lookup   TCP Connection   TLS Handshake   Server Processing   Content Transfer\n")
+	fmt.Fprintf(b, "[%s|%s|%s|%s|%s]\n",
+		cell(t.DNSLookup, 12), cell(t.TCPConnection, 16), cell(t.TLSHandshake, 15),
+		cell(t.ServerProcessing, 19), cell(t.ContentTransfer, 18))
+
+	connect := t.DNSLookup + t.TCPConnection
+	fmt.Fprintf(b, "  namelookup: %v  connect: %v  pretransfer: %v  starttransfer: %v  total: %v\n",
+		t.DNSLookup.Round(time.Millisecond), connect.Round(time.Millisecond),
+		(connect + t.TLSHandshake).Round(time.Millisecond),
+		t.TimeToFirstByte.Round(time.Millisecond), t.Total.Round(time.Millisecond))
+
+	return b.String()
+}
+
+// timer records the time of each request phase from httptrace callbacks.
+// The transport may call them from several goroutines, e.g. when it dials
+// the addresses of a host in parallel, so the fields are guarded by mu.
+type timer struct {
+	mu         sync.Mutex
+	start      time.Time
+	dnsStart   time.Time
+	dnsDone    time.Time
+	connStart  time.Time
+	connDone   time.Time
+	tlsStart   time.Time
+	tlsDone    time.Time
+	wrote      time.Time
+	firstByte  time.Time
+	connReused bool
+}
+
+func (t *timer) trace(ct *httptrace.ClientTrace) {
+	ct.DNSStart = func(httptrace.DNSStartInfo) { t.now(&t.dnsStart) }
+	ct.DNSDone = func(httptrace.DNSDoneInfo) { t.now(&t.dnsDone) }
+	ct.ConnectStart = func(string, string) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		// with happy eyeballs several connects may run, keep the first
+		if t.connStart.IsZero() {
+			t.connStart = time.Now()
+		}
+	}
+	ct.ConnectDone = func(string, string, error) { t.now(&t.connDone) }
+	ct.TLSHandshakeStart = func() { t.now(&t.tlsStart) }
+	ct.TLSHandshakeDone = func(tls.ConnectionState, error) { t.now(&t.tlsDone) }
+	ct.WroteRequest = func(httptrace.WroteRequestInfo) { t.now(&t.wrote) }
+	ct.GotFirstResponseByte = func() { t.now(&t.firstByte) }
+}
+
+// now sets the phase time at field to the current time.
+func (t *timer) now(field *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	*field = time.Now()
+}
+
+func (t *timer) gotConn(reused bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.connReused = reused
+}
+
+func (t *timer) timings(end time.Time) Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	firstByte := t.firstByte
+	if firstByte.IsZero() {
+		firstByte = end
+	}
+
+	return Timings{
+		DNSLookup:        between(t.dnsStart, t.dnsDone),
+		TCPConnection:    between(t.connStart, t.connDone),
+		TLSHandshake:     between(t.tlsStart, t.tlsDone),
+		ServerProcessing: between(t.wrote, firstByte),
+		TimeToFirstByte:  between(t.start, firstByte),
+		ContentTransfer:  between(firstByte, end),
+		Total:            between(t.start, end),
+		ConnReused:       t.connReused,
+	}
+}
+
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -0,0 +1,61 @@
+package net
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcher_ForwardCliEvent_Timings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write(successBody)
+	}))
+	defer srv.Close()
+
+	d, err := NewDispatcher(time.Second, "")
+	require.NoError(t, err)
+
+	res, err := d.ForwardCliEvent(srv.URL, http.MethodPost, []byte(`{}`), nil)
+	require.NoError(t, err)
+
+	timings := res.Timings
+	require.False(t, timings.ConnReused)
+	require.Greater(t, timings.TCPConnection, time.Duration(0))
+	require.GreaterOrEqual(t, timings.ServerProcessing, 20*time.Millisecond)
+	require.GreaterOrEqual(t, timings.TimeToFirstByte, timings.ServerProcessing)
+	require.GreaterOrEqual(t, timings.Total, timings.TimeToFirstByte)
+	require.Contains(t, timings.Format(), "Server Processing")
+
+	// the second request reuses the connection, so there is no connect phase
+	res, err = d.ForwardCliEvent(srv.URL, http.MethodPost, []byte(`{}`), nil)
+	require.NoError(t, err)
+	require.True(t, res.Timings.ConnReused)
+	require.Equal(t, time.Duration(0), res.Timings.TCPConnection)
+}
+
+func TestTimer_ParallelConnects(t *testing.T) {
+	timer := &timer{start: time.Now()}
+	ct := &httptrace.ClientTrace{}
+	timer.trace(ct)
+
+	// the transport races connects to every address of a host
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ct.ConnectStart("tcp", "127.0.0.1:80")
+			ct.ConnectDone("tcp", "127.0.0.1:80", nil)
+			timer.timings(time.Now())
+		}()
+	}
+	wg.Wait()
+
+	require.Greater(t, timer.timings(time.Now()).Total, time.Duration(0))
+}
@@ -0,0 +1,172 @@
+package convoy_cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/frain-dev/convoy-cli/util"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ConfigEnv    = "CONVOY_CONFIG"
+	ProfileEnv   = "CONVOY_PROFILE"
+	HostEnv      = "CONVOY_HOST"
+	ApiKeyEnv    = "CONVOY_API_KEY"
+	ProjectIDEnv = "CONVOY_PROJECT_ID"
+)
+
+// ConfigOverrides are config values given on the command line. Each value
+// is resolved from the flag, then the environment, then the project file
+// (.convoy.yml) and finally the user config.
+type ConfigOverrides struct {
+	// Path is the config file, ~/.convoy/config when empty
+	Path string
+
+	Profile string
+	Host    string
+	ApiKey  string
+
+	// ProjectID is the id or name of the project, it replaces the active project
+	ProjectID string
+
+	// ProjectFile is the .convoy.yml read for defaults, the nearest one to
+	// the working directory when empty
+	ProjectFile string
+
+	// Locked is set when the caller holds the config lock, see LockConfig
+	Locked bool
+}
+
+// resolve fills each value from the flag, the environment or the project file.
+func (o ConfigOverrides) resolve() (ConfigOverrides, error) {
+	path := firstNonEmpty(o.Path, os.Getenv(ConfigEnv))
+	if util.IsStringEmpty(path) {
+		homedir, err := os.UserHomeDir()
+		if err != nil {
+			return o, err
+		}
+		path = filepath.Join(homedir, defaultConfigDir)
+	}
+
+	projectFile := o.ProjectFile
+	if util.IsStringEmpty(projectFile) {
+		var err error
+		projectFile, err = FindSessionConfig(".")
+		if err != nil {
+			return o, err
+		}
+	}
+
+	var defaults SessionConfig
+	if !util.IsStringEmpty(projectFile) {
+		data, err := os.ReadFile(projectFile)
+		if err != nil {
+			return o, err
+		}
+
+		err = yaml.Unmarshal(data, &defaults)
+		if err != nil {
+			return o, fmt.Errorf("failed to parse project file %s: %v", projectFile, err)
+		}
+	}
+
+	return ConfigOverrides{
+		Path:        path,
+		Profile:     firstNonEmpty(o.Profile, os.Getenv(ProfileEnv), defaults.Profile),
+		Host:        firstNonEmpty(o.Host, os.Getenv(HostEnv), defaults.Host),
+		ApiKey:      firstNonEmpty(o.ApiKey, os.Getenv(ApiKeyEnv)),
+		ProjectID:   firstNonEmpty(o.ProjectID, os.Getenv(ProjectIDEnv), defaults.Project),
+		ProjectFile: projectFile,
+		Locked:      o.Locked,
+	}, nil
+}
+
+// ProjectOverride names what replaces the active project of the profile in
+// use: the --project flag, $CONVOY_PROJECT_ID or the session config setting
+// a project. It returns an empty string when the active project is used.
+func ProjectOverride(o ConfigOverrides) (string, error) {
+	if !util.IsStringEmpty(o.ProjectID) {
+		return "the --project flag", nil
+	}
+
+	if !util.IsStringEmpty(os.Getenv(ProjectIDEnv)) {
+		return "$" + ProjectIDEnv, nil
+	}
+
+	o, err := o.resolve()
+	if err != nil {
+		return "", err
+	}
+
+	if !util.IsStringEmpty(o.ProjectID) {
+		return o.ProjectFile, nil
+	}
+	return "", nil
+}
+
+// applyOverrides sets the overridden values on a copy of the profile in use, so
+// they are never written to the config file.
+func (c *Config) applyOverrides(o ConfigOverrides) {
+	if util.IsStringEmpty(o.Host) && util.IsStringEmpty(o.ApiKey) && util.IsStringEmpty(o.ProjectID) {
+		return
+	}
+
+	p := *c.Profile
+	if !util.IsStringEmpty(o.Host) {
+		if IsNewHost(p.Host, o.Host) {
+			// the projects and devices belong to the profile's host
+			p.ActiveProjectID = ""
+			p.Projects = nil
+		}
+		p.Host = o.Host
+	}
+
+	if !util.IsStringEmpty(o.ApiKey) {
+		p.ActiveApiKey = o.ApiKey
+	}
+
+	if !util.IsStringEmpty(o.ProjectID) {
+		p.ActiveProjectID = o.ProjectID
+	}
+
+	c.Profile = &p
+	c.overrides = o
+}
+
+// writeBackProfile copies the changes made to the profile in use to the
+// profile written to the config file, without the overridden values.
+func (c *Config) writeBackProfile() {
+	file, ok := c.Profiles[c.profileName]
+	if !ok || file == c.Profile {
+		return
+	}
+
+	p := *c.Profile
+	if !util.IsStringEmpty(c.overrides.Host) {
+		p.Host = file.Host
+		p.Projects = file.Projects
+	}
+
+	if !util.IsStringEmpty(c.overrides.ApiKey) {
+		p.ActiveApiKey = file.ActiveApiKey
+		p.ApiKeyRef = file.ApiKeyRef
+		p.storedApiKey = file.storedApiKey
+	}
+
+	if !util.IsStringEmpty(c.overrides.ProjectID) || !util.IsStringEmpty(c.overrides.Host) {
+		p.ActiveProjectID = file.ActiveProjectID
+	}
+
+	*file = p
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if !util.IsStringEmpty(v) {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package convoy_cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_Overrides(t *testing.T) {
+	path := writeConfigFile(t, `
+active_profile: cloud
+profiles:
+  cloud:
+    host: https://cli.getconvoy.io
+    active_api_key: cloud-key
+    active_project_id: p1
+    projects:
+      - uid: p1
+        name: payments
+  local:
+    host: http://localhost:5005
+    active_api_key: local-key
+`)
+
+	projectFile := filepath.Join(t.TempDir(), SessionConfigName)
+	require.NoError(t, os.WriteFile(projectFile, []byte("project: ci\nstreams: []\n"), 0600))
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		overrides ConfigOverrides
+		want      Profile
+	}{
+		{
+			name: "user config",
+			want: Profile{Host: "https://cli.getconvoy.io", ActiveApiKey: "cloud-key", ActiveProjectID: "p1"},
+		},
+		{
+			name:      "project file",
+			overrides: ConfigOverrides{ProjectFile: projectFile},
+			want:      Profile{Host: "https://cli.getconvoy.io", ActiveApiKey: "cloud-key", ActiveProjectID: "ci"},
+		},
+		{
+			name:      "env over project file",
+			env:       map[string]string{ProjectIDEnv: "p2", ApiKeyEnv: "env-key"},
+			overrides: ConfigOverrides{ProjectFile: projectFile},
+			want:      Profile{Host: "https://cli.getconvoy.io", ActiveApiKey: "env-key", ActiveProjectID: "p2"},
+		},
+		{
+			name:      "flags over env",
+			env:       map[string]string{ProjectIDEnv: "p2", ProfileEnv: "cloud"},
+			overrides: ConfigOverrides{ProjectID: "p3", Profile: "local"},
+			want:      Profile{Host: "http://localhost:5005", ActiveApiKey: "local-key", ActiveProjectID: "p3"},
+		},
+		{
+			name: "another host drops the profile's projects",
+			env:  map[string]string{HostEnv: "https://convoy.example.com"},
+			want: Profile{Host: "https://convoy.example.com", ActiveApiKey: "cloud-key"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			c, err := LoadConfig(tt.overrides)
+			require.NoError(t, err)
+			require.Equal(t, tt.want.Host, c.Host)
+			require.Equal(t, tt.want.ActiveApiKey, c.ActiveApiKey)
+			require.Equal(t, tt.want.ActiveProjectID, c.ActiveProjectID)
+		})
+	}
+
+	// overridden values are never written
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	t.Setenv(HostEnv, "https://convoy.example.com")
+	t.Setenv(ApiKeyEnv, "env-key")
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.NoError(t, c.WriteToDisk())
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(before), string(after))
+}
+
+func TestProjectOverride(t *testing.T) {
+	dir := t.TempDir()
+	projectFile := filepath.Join(dir, SessionConfigName)
+	require.NoError(t, os.WriteFile(projectFile, []byte("project: ci\nstreams: []\n"), 0600))
+
+	noProjectFile := filepath.Join(dir, "other.yml")
+	require.NoError(t, os.WriteFile(noProjectFile, []byte("streams: []\n"), 0600))
+
+	tests := []struct {
+		name      string
+		env       string
+		overrides ConfigOverrides
+		want      string
+	}{
+		{name: "active project", overrides: ConfigOverrides{ProjectFile: noProjectFile}},
+		{name: "flag", overrides: ConfigOverrides{ProjectID: "p2", ProjectFile: projectFile}, want: "the --project flag"},
+		{name: "env", env: "p2", overrides: ConfigOverrides{ProjectFile: projectFile}, want: "$" + ProjectIDEnv},
+		{name: "session config", overrides: ConfigOverrides{ProjectFile: projectFile}, want: projectFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ProjectIDEnv, tt.env)
+
+			got, err := ProjectOverride(tt.overrides)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadConfig_WithoutConfigFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(ConfigEnv, filepath.Join(t.TempDir(), "config"))
+
+	_, err := LoadConfig(ConfigOverrides{})
+	require.Error(t, err)
+
+	t.Setenv(HostEnv, "https://convoy.example.com")
+	t.Setenv(ApiKeyEnv, "env-key")
+
+	c, err := LoadConfig(ConfigOverrides{ProjectID: "p1"})
+	require.NoError(t, err)
+	require.False(t, c.HasDefaultConfigFile())
+	require.Equal(t, "https://convoy.example.com", c.Host)
+	require.Equal(t, "env-key", c.ActiveApiKey)
+	require.Equal(t, "p1", c.ActiveProjectID)
+}
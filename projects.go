@@ -0,0 +1,106 @@
+package convoy_cli
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/frain-dev/convoy-cli/util"
+)
+
+type Source struct {
+	UID        string `json:"uid"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	MaskID     string `json:"mask_id,omitempty"`
+	Provider   string `json:"provider,omitempty"`
+	IsDisabled bool   `json:"is_disabled"`
+}
+
+// ProjectDetails is a project with the sources and endpoints the host has
+// for it.
+type ProjectDetails struct {
+	Project   *Project   `json:"project"`
+	Sources   []Source   `json:"sources"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// GetProject returns the details of a project the api key has access to.
+func (c *Client) GetProject(projectID string) (*ProjectDetails, error) {
+	if util.IsStringEmpty(projectID) {
+		return nil, errors.New("project id is required")
+	}
+
+	resp, err := c.send(http.MethodGet, "/stream/projects/"+url.PathEscape(projectID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkSupported(resp, "project details"); err != nil {
+		return nil, err
+	}
+
+	if err = checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var details ProjectDetails
+	err = json.Unmarshal(resp.Body, &details)
+	if err != nil {
+		return nil, err
+	}
+
+	if details.Project == nil {
+		return nil, errors.New("the host returned no project")
+	}
+	return &details, nil
+}
+
+// MatchProjects returns the projects an id or name refers to. An exact id or
+// name wins, then projects whose id or name starts with it, then the names
+// containing it and last the names containing its letters in order, so
+// "pymnts" matches "payments". It's case insensitive.
+func MatchProjects(projects []ConfigProject, idOrName string) []ConfigProject {
+	query := strings.ToLower(strings.TrimSpace(idOrName))
+	if query == "" {
+		return nil
+	}
+
+	matchers := []func(id, name string) bool{
+		func(id, name string) bool { return id == query },
+		func(id, name string) bool { return name == query },
+		func(id, name string) bool { return strings.HasPrefix(id, query) || strings.HasPrefix(name, query) },
+		func(id, name string) bool { return strings.Contains(name, query) },
+		func(id, name string) bool { return isSubsequence(query, name) },
+	}
+
+	for _, match := range matchers {
+		var matches []ConfigProject
+		for _, p := range projects {
+			if match(strings.ToLower(p.UID), strings.ToLower(strings.TrimSpace(p.Name))) {
+				matches = append(matches, p)
+			}
+		}
+
+		if len(matches) > 0 {
+			return matches
+		}
+	}
+
+	return nil
+}
+
+// isSubsequence reports whether s has the letters of sub in order.
+func isSubsequence(sub, s string) bool {
+	for _, r := range s {
+		if len(sub) == 0 {
+			break
+		}
+		if strings.HasPrefix(sub, string(r)) {
+			sub = sub[len(string(r)):]
+		}
+	}
+	return len(sub) == 0
+}
@@ -0,0 +1,88 @@
+package convoy_cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchProjects(t *testing.T) {
+	projects := []ConfigProject{
+		{UID: "01H1", Name: "Payments"},
+		{UID: "01H2", Name: "Payments Staging"},
+		{UID: "01H3", Name: "CI"},
+		{UID: "02A4", Name: "Invoices"},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "01h3", want: []string{"01H3"}},
+		{query: "payments", want: []string{"01H1"}},
+		{query: "Pay", want: []string{"01H1", "01H2"}},
+		{query: "02", want: []string{"02A4"}},
+		{query: "staging", want: []string{"01H2"}},
+		{query: "invcs", want: []string{"02A4"}},
+		{query: "01H", want: []string{"01H1", "01H2", "01H3"}},
+		{query: "webhooks", want: nil},
+		{query: " ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got []string
+			for _, p := range MatchProjects(projects, tt.query) {
+				got = append(got, p.UID)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClient_GetProject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+
+		switch {
+		case r.URL.Path == "/stream/projects/p9":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "project not found")
+			return
+		case r.Method != http.MethodGet || r.URL.Path != "/stream/projects/p1":
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprint(w, `{
+			"project": {"uid": "p1", "name": "payments", "type": "incoming"},
+			"sources": [{"uid": "s1", "name": "stripe", "type": "http", "mask_id": "abc", "provider": "stripe"}],
+			"endpoints": [{"uid": "e1", "title": "api", "target_url": "https://example.com/webhooks"}]
+		}`)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, "key")
+	require.NoError(t, err)
+
+	details, err := client.GetProject("p1")
+	require.NoError(t, err)
+	require.Equal(t, "payments", details.Project.Name)
+	require.Equal(t, "abc", details.Sources[0].MaskID)
+	require.Equal(t, "https://example.com/webhooks", details.Endpoints[0].TargetURL)
+
+	_, err = client.GetProject("p9")
+	require.ErrorContains(t, err, "project not found")
+
+	// a plain 404 is the router of a host before CLIAPIVersion
+	_, err = client.GetProject("p2")
+	require.ErrorIs(t, err, ErrUnsupported)
+	require.ErrorContains(t, err, "project details requires Convoy "+CLIAPIVersion)
+
+	_, err = client.GetProject("")
+	require.Error(t, err)
+}
@@ -0,0 +1,208 @@
+package convoy_cli
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+// Time to wait for more changes before reloading, editors often write a file in several steps.
+const reloadDebounce = 200 * time.Millisecond
+
+// Reloader rebuilds the listen requests of a session after its config
+// changed, it returns an error when the new config is invalid.
+type Reloader func() (*Config, []*ListenRequest, error)
+
+// SetReloader makes the listener apply the config returned by r on SIGHUP
+// and when a file watched by WatchConfig changes, instead of ending the session.
+func (l *Listener) SetReloader(r Reloader) {
+	l.reloader = r
+}
+
+// WatchConfig reloads the session when one of the files at paths is written.
+func (l *Listener) WatchConfig(paths ...string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	watched := map[string]struct{}{}
+	for _, path := range paths {
+		path, err = filepath.Abs(path)
+		if err != nil {
+			watcher.Close()
+			return err
+		}
+		watched[path] = struct{}{}
+
+		// watching the directory keeps working when an editor replaces the file
+		if err = watcher.Add(filepath.Dir(path)); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if _, ok := watched[e.Name]; !ok || e.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				debounce = time.After(reloadDebounce)
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.WithError(err).Errorln("config watcher failed")
+
+			case <-debounce:
+				debounce = nil
+				log.Println("session config changed, reloading")
+				select {
+				case l.reloads <- struct{}{}:
+				default:
+				}
+
+			case <-l.finished:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (l *Listener) reload() {
+	c, listenRequests, err := l.reloader()
+	if err != nil {
+		log.WithError(err).Errorln("invalid session config, keeping the current one")
+		return
+	}
+
+	err = l.Reload(c, listenRequests)
+	if err != nil {
+		log.WithError(err).Errorln("failed to apply the session config, keeping the current one")
+	}
+}
+
+// Reload applies a new config and listen requests to a running session.
+// Streams for the same host, project and source keep their connection and
+// handle the next event with the new request. Other streams are replaced:
+// connections for the new requests are opened first and resume from when the
+// oldest replaced stream connected, so the events it received but didn't
+// acknowledge are sent again, then the old ones are closed once their
+// in-flight event was delivered.
+func (l *Listener) Reload(c *Config, listenRequests []*ListenRequest) error {
+	hostInfo, err := url.Parse(c.Host)
+	if err != nil {
+		return err
+	}
+
+	previous := l.c
+	l.c = c
+
+	current := map[string]*stream{}
+	for _, s := range l.currentStreams() {
+		current[s.key()] = s
+	}
+
+	kept := map[string]struct{}{}
+	for _, listenRequest := range listenRequests {
+		kept[streamKey(hostInfo, listenRequest)] = struct{}{}
+	}
+
+	// events aren't acknowledged in the order they arrive, so the time a
+	// replaced stream connected is the only point none of its events precede
+	resume := time.Now()
+	for key, s := range current {
+		if _, ok := kept[key]; !ok && s.started.Before(resume) {
+			resume = s.started
+		}
+	}
+	since := fmt.Sprintf("since|timestamp|%s", resume.UTC().Format(time.RFC3339))
+
+	type update struct {
+		stream  *stream
+		request *ListenRequest
+	}
+
+	var updates []update
+	var streams, added []*stream
+	for _, listenRequest := range listenRequests {
+		key := streamKey(hostInfo, listenRequest)
+		if s, ok := current[key]; ok {
+			delete(current, key)
+			updates = append(updates, update{s, listenRequest})
+			streams = append(streams, s)
+			continue
+		}
+
+		listenRequest.Since = since
+		s, err := l.dial(listenRequest, hostInfo)
+		if err != nil {
+			for _, s := range added {
+				l.close(s)
+			}
+			l.c = previous
+			return err
+		}
+
+		added = append(added, s)
+		streams = append(streams, s)
+	}
+
+	for _, u := range updates {
+		u.stream.setRequest(u.request)
+	}
+
+	l.streamsMu.Lock()
+	l.streams = streams
+	l.streamsMu.Unlock()
+
+	for _, s := range added {
+		s.log.Println("listening")
+		l.metrics.Reconnected()
+		go l.HandleMessage(s)
+	}
+
+	for _, s := range current {
+		l.retire(s)
+	}
+
+	log.Printf("session config reloaded, %d streams updated, %d opened, %d closed", len(updates), len(added), len(current))
+	return nil
+}
+
+// retire closes a stream replaced by a reload once its in-flight event was
+// delivered, events received after that are redelivered to the new stream.
+func (l *Listener) retire(s *stream) {
+	s.retired.Store(true)
+
+	s.busy.Lock()
+	s.busy.Unlock()
+
+	l.close(s)
+}
+
+func (s *stream) key() string {
+	return streamKey(s.host, s.request.Load())
+}
+
+// streamKey identifies the connection a listen request needs, requests with
+// the same key can share it.
+func streamKey(hostInfo *url.URL, listenRequest *ListenRequest) string {
+	return hostInfo.Host + "|" + listenRequest.ProjectID + "|" + listenRequest.SourceName
+}
@@ -0,0 +1,90 @@
+package convoy_cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListener_Reload(t *testing.T) {
+	var mu sync.Mutex
+	var connected []ListenRequest
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ListenRequest
+		require.NoError(t, json.Unmarshal([]byte(r.Header.Get("Body")), &req))
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		mu.Lock()
+		connected = append(connected, req)
+		mu.Unlock()
+
+		for {
+			if _, _, err = conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	hostInfo, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	c := &Config{Profile: &Profile{Host: srv.URL}}
+	l := NewListener(c)
+
+	done := make(chan error)
+	go func() {
+		done <- l.ListenAll([]*ListenRequest{{ProjectID: "p1", SourceName: "stripe", ForwardTo: "http://localhost:8080"}}, hostInfo)
+	}()
+
+	require.Eventually(t, l.ready.Load, 5*time.Second, 10*time.Millisecond)
+	first := l.currentStreams()[0]
+
+	// a new target keeps the connection
+	err = l.Reload(c, []*ListenRequest{{ProjectID: "p1", SourceName: "stripe", ForwardTo: "http://localhost:9000"}})
+	require.NoError(t, err)
+	require.Len(t, l.currentStreams(), 1)
+	require.Same(t, first, l.currentStreams()[0])
+	require.Equal(t, "http://localhost:9000", first.request.Load().ForwardTo)
+
+	// a new source reconnects and resumes from when the replaced stream connected
+	first.started = time.Now().Add(-time.Hour)
+	err = l.Reload(c, []*ListenRequest{{ProjectID: "p1", SourceName: "github", ForwardTo: "http://localhost:9000"}})
+	require.NoError(t, err)
+	require.Len(t, l.currentStreams(), 1)
+
+	second := l.currentStreams()[0]
+	require.NotSame(t, first, second)
+	require.Equal(t, "github", second.request.Load().SourceName)
+	require.Equal(t, "since|timestamp|"+first.started.UTC().Format(time.RFC3339), second.request.Load().Since)
+	require.False(t, first.connected.Load())
+
+	// a host that can't be reached keeps the current streams
+	err = l.Reload(&Config{Profile: &Profile{Host: "http://127.0.0.1:1"}}, []*ListenRequest{{ProjectID: "p1", SourceName: "github"}})
+	require.Error(t, err)
+	require.Same(t, second, l.currentStreams()[0])
+	require.Same(t, c, l.c)
+
+	mu.Lock()
+	require.Len(t, connected, 2)
+	require.Equal(t, "stripe", connected[0].SourceName)
+	require.Equal(t, "github", connected[1].SourceName)
+	mu.Unlock()
+
+	l.finish(nil)
+	require.NoError(t, <-done)
+}
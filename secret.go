@@ -0,0 +1,313 @@
+package convoy_cli
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	SecretStoreKeyring = "keyring"
+	SecretStoreFile    = "file"
+
+	// PassphraseEnv and KeyFileEnv unlock the encrypted credentials file
+	// without a prompt, e.g. on a headless machine or for a background listener.
+	PassphraseEnv = "CONVOY_CLI_PASSPHRASE"
+	KeyFileEnv    = "CONVOY_CLI_KEY_FILE"
+
+	keyringService  = "convoy-cli"
+	credentialsFile = "credentials"
+)
+
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretStore keeps the api keys the config refers to.
+type SecretStore interface {
+	Get(id string) (string, error)
+	Set(id, secret string) error
+	Delete(id string) error
+}
+
+// newSecretStore returns the named store, the encrypted file store keeps its
+// file in dir.
+func newSecretStore(name, dir string) (SecretStore, error) {
+	switch name {
+	case SecretStoreKeyring:
+		return keyringStore{}, nil
+	case SecretStoreFile:
+		return &fileStore{path: filepath.Join(dir, credentialsFile)}, nil
+	default:
+		return nil, fmt.Errorf("unknown secret store %q, use %s or %s", name, SecretStoreKeyring, SecretStoreFile)
+	}
+}
+
+// secretRef is how the config refers to a secret: the store and the id of
+// the secret in it, e.g. keyring:5d0a6a9e-...
+func secretRef(store, id string) string {
+	return store + ":" + id
+}
+
+func parseSecretRef(ref string) (string, string, error) {
+	store, id, ok := strings.Cut(ref, ":")
+	if !ok || store == "" || id == "" {
+		return "", "", fmt.Errorf("invalid api key reference %q", ref)
+	}
+
+	return store, id, nil
+}
+
+// keyringStore keeps secrets in the OS keyring: the Secret Service on Linux,
+// the Keychain on macOS and the Credential Manager on Windows.
+type keyringStore struct{}
+
+func (keyringStore) Get(id string) (string, error) {
+	secret, err := keyring.Get(keyringService, id)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrSecretNotFound
+	}
+	return secret, err
+}
+
+func (keyringStore) Set(id, secret string) error {
+	return keyring.Set(keyringService, id, secret)
+}
+
+func (keyringStore) Delete(id string) error {
+	err := keyring.Delete(keyringService, id)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrSecretNotFound
+	}
+	return err
+}
+
+// fileStore keeps secrets in a file encrypted with AES-GCM, the key is
+// derived from a passphrase with scrypt.
+type fileStore struct {
+	path       string
+	passphrase []byte
+}
+
+type encryptedFile struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+func (f *fileStore) Get(id string) (string, error) {
+	secrets, err := f.read()
+	if err != nil {
+		return "", err
+	}
+
+	secret, ok := secrets[id]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return secret, nil
+}
+
+func (f *fileStore) Set(id, secret string) error {
+	unlock, err := lockFile(f.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	secrets[id] = secret
+	return f.write(secrets)
+}
+
+func (f *fileStore) Delete(id string) error {
+	unlock, err := lockFile(f.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := secrets[id]; !ok {
+		return ErrSecretNotFound
+	}
+
+	delete(secrets, id)
+	if len(secrets) == 0 {
+		return os.Remove(f.path)
+	}
+	return f.write(secrets)
+}
+
+func (f *fileStore) read() (map[string]string, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file encryptedFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt credentials file %s: %v", f.path, err)
+	}
+
+	gcm, err := f.cipher(file.Salt, false)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s, wrong passphrase or corrupt file", f.path)
+	}
+
+	secrets := map[string]string{}
+	err = json.Unmarshal(plaintext, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt credentials file %s: %v", f.path, err)
+	}
+
+	return secrets, nil
+}
+
+func (f *fileStore) write(secrets map[string]string) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	file := encryptedFile{Salt: make([]byte, 16)}
+	if _, err = rand.Read(file.Salt); err != nil {
+		return err
+	}
+
+	// the passphrase is only asked for here when the file doesn't exist yet
+	_, err = os.Stat(f.path)
+	gcm, err := f.cipher(file.Salt, errors.Is(err, os.ErrNotExist))
+	if err != nil {
+		return err
+	}
+
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(f.path, data, 0600)
+}
+
+func (f *fileStore) cipher(salt []byte, create bool) (cipher.AEAD, error) {
+	if f.passphrase == nil {
+		passphrase, err := readPassphrase(create)
+		if err != nil {
+			return nil, err
+		}
+		f.passphrase = passphrase
+	}
+
+	key, err := scrypt.Key(f.passphrase, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// readPassphrase reads the passphrase of the credentials file from the
+// environment or a key file, and prompts for it on a terminal. A passphrase
+// chosen for a new file is prompted twice, so a typo doesn't lock it.
+func readPassphrase(create bool) ([]byte, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	if path := os.Getenv(KeyFileEnv); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %v", err)
+		}
+
+		key := strings.TrimSpace(string(data))
+		if key == "" {
+			return nil, fmt.Errorf("key file %s is empty", path)
+		}
+		return []byte(key), nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		if create {
+			return nil, fmt.Errorf("set %s or %s to encrypt the credentials file", PassphraseEnv, KeyFileEnv)
+		}
+		return nil, fmt.Errorf("the credentials file is encrypted, set %s or %s to unlock it", PassphraseEnv, KeyFileEnv)
+	}
+
+	return promptPassphrase(func(prompt string) ([]byte, error) {
+		fmt.Fprint(os.Stderr, prompt)
+		passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return passphrase, err
+	}, create)
+}
+
+// promptPassphrase asks for the passphrase with read, and asks again to
+// confirm it when the credentials file is created.
+func promptPassphrase(read func(prompt string) ([]byte, error), create bool) ([]byte, error) {
+	prompt := "Passphrase for the convoy-cli credentials file: "
+	if create {
+		prompt = "New passphrase for the convoy-cli credentials file: "
+	}
+
+	passphrase, err := read(prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase cannot be empty")
+	}
+
+	if !create {
+		return passphrase, nil
+	}
+
+	confirmation, err := read("Confirm the passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(passphrase, confirmation) {
+		return nil, errors.New("the passphrases don't match")
+	}
+	return passphrase, nil
+}
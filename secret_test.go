@@ -0,0 +1,130 @@
+package convoy_cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(PassphraseEnv, "correct horse")
+
+	s, err := newSecretStore(SecretStoreFile, dir)
+	require.NoError(t, err)
+
+	_, err = s.Get("a")
+	require.ErrorIs(t, err, ErrSecretNotFound)
+
+	require.NoError(t, s.Set("a", "key-a"))
+	require.NoError(t, s.Set("b", "key-b"))
+
+	path := filepath.Join(dir, credentialsFile)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "key-a")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	secret, err := s.Get("a")
+	require.NoError(t, err)
+	require.Equal(t, "key-a", secret)
+
+	// the passphrase can come from a key file
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("correct horse\n"), 0600))
+	t.Setenv(PassphraseEnv, "")
+	t.Setenv(KeyFileEnv, keyFile)
+
+	s, err = newSecretStore(SecretStoreFile, dir)
+	require.NoError(t, err)
+
+	secret, err = s.Get("b")
+	require.NoError(t, err)
+	require.Equal(t, "key-b", secret)
+
+	t.Setenv(KeyFileEnv, "")
+	t.Setenv(PassphraseEnv, "wrong")
+	s, err = newSecretStore(SecretStoreFile, dir)
+	require.NoError(t, err)
+
+	_, err = s.Get("a")
+	require.Error(t, err)
+}
+
+func TestPromptPassphrase(t *testing.T) {
+	tests := []struct {
+		name    string
+		create  bool
+		answers []string
+		wantErr string
+	}{
+		{name: "unlock", answers: []string{"pw"}},
+		{name: "create", create: true, answers: []string{"pw", "pw"}},
+		{name: "create with a typo", create: true, answers: []string{"pw", "wp"}, wantErr: "the passphrases don't match"},
+		{name: "empty", create: true, answers: []string{""}, wantErr: "passphrase cannot be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompts []string
+			passphrase, err := promptPassphrase(func(prompt string) ([]byte, error) {
+				prompts = append(prompts, prompt)
+				return []byte(tt.answers[len(prompts)-1]), nil
+			}, tt.create)
+
+			require.Len(t, prompts, len(tt.answers))
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "pw", string(passphrase))
+		})
+	}
+}
+
+func TestConfig_StoreApiKey(t *testing.T) {
+	writeConfigFile(t, `
+active_profile: local
+profiles:
+  local:
+    host: http://localhost:5005
+    active_api_key: key
+`)
+	t.Setenv(PassphraseEnv, "correct horse")
+
+	// without a keyring the key goes to the encrypted file
+	keyring.MockInitWithError(errors.New("no secret service"))
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.Regexp(t, "^file:", c.ApiKeyRef)
+	require.Equal(t, "key", c.ActiveApiKey)
+
+	// a configured store moves the key
+	keyring.MockInit()
+	c.SecretStore = SecretStoreKeyring
+	c.ActiveApiKey = "new-key"
+	require.NoError(t, c.WriteToDisk())
+	require.Regexp(t, "^keyring:", c.ApiKeyRef)
+
+	c, err = LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.Equal(t, "new-key", c.ActiveApiKey)
+
+	// deleting the profile deletes the key
+	_, id, err := parseSecretRef(c.ApiKeyRef)
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteProfile("local"))
+
+	_, err = keyringStore{}.Get(id)
+	require.ErrorIs(t, err, ErrSecretNotFound)
+}
@@ -0,0 +1,258 @@
+package convoy_cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SessionConfigName is the project-local session config discovered by listen.
+const SessionConfigName = ".convoy.yml"
+
+// SessionConfig describes the streams of a listen session, each stream is
+// one websocket connection receiving the events of a project and source.
+type SessionConfig struct {
+	// Profile and Host select the Convoy instance, for every command run
+	// in the directory of the file unless flags or the environment set them
+	Profile string `yaml:"profile,omitempty"`
+	Host    string `yaml:"host,omitempty"`
+
+	// Project is the id or name of the project of streams that don't set one
+	Project string `yaml:"project,omitempty"`
+
+	// Since is the --since of streams that don't set one
+	Since string `yaml:"since,omitempty"`
+
+	Streams []StreamConfig `yaml:"streams"`
+}
+
+type StreamConfig struct {
+	// Project is the id or name of the project, the active project when empty
+	Project   string `yaml:"project,omitempty"`
+	Source    string `yaml:"source"`
+	ForwardTo string `yaml:"forward_to,omitempty"`
+
+	// Since overrides the session's --since for this stream
+	Since string `yaml:"since,omitempty"`
+
+	// Events are glob patterns of the event types forwarded, all when empty.
+	// Convoy doesn't stream the event type, EventTypeField is the path it is
+	// read from, e.g. data.type
+	Events         []string `yaml:"events,omitempty"`
+	EventTypeField string   `yaml:"event_type_field,omitempty"`
+
+	// Filter is an expression events must match to be forwarded
+	Filter string `yaml:"filter,omitempty"`
+
+	Headers   map[string]string `yaml:"headers,omitempty"`
+	Transform *Transform        `yaml:"transform,omitempty"`
+}
+
+// LoadSessionConfig reads a session config file, e.g.
+//
+//	project: payments
+//	streams:
+//	  - source: stripe
+//	    forward_to: http://localhost:8080/stripe
+//	    events: ["invoice.*"]
+//	    event_type_field: data.type
+//	    filter: data.amount >= 100
+//	    headers:
+//	      X-Environment: local
+//	    transform:
+//	      remove: [customer.email]
+//	  - project: 01GJ2V0Y7PJ6F1Q7N0N2CZ5N6B
+//	    source: github
+//	    forward_to: http://localhost:8081/github
+func LoadSessionConfig(path string) (*SessionConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SessionConfig{}
+	err = yaml.Unmarshal(data, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse session config %s: %v", path, err)
+	}
+
+	if len(s.Streams) == 0 {
+		return nil, fmt.Errorf("session config %s has no streams", path)
+	}
+
+	for i := range s.Streams {
+		if _, err = s.Streams[i].EventFilter(); err != nil {
+			return nil, fmt.Errorf("session config %s: %v", path, err)
+		}
+	}
+
+	return s, nil
+}
+
+// FindSessionConfig looks for a .convoy.yml in dir and its parents up to the
+// root of the git repository or the home directory dir is in, and returns an
+// empty path when there is none. A file above them belongs to another project.
+func FindSessionConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	home, _ := os.UserHomeDir()
+
+	for {
+		path := filepath.Join(dir, SessionConfigName)
+		if _, err = os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		if dir == home {
+			return "", nil
+		}
+
+		// .git is a file in worktrees and submodules
+		if _, err = os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// WriteSessionConfig writes s to path, it fails if the file exists unless overwrite is set.
+func WriteSessionConfig(path string, s *SessionConfig, overwrite bool) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flag |= os.O_EXCL
+	}
+
+	f, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s already exists", path)
+		}
+		return err
+	}
+
+	_, err = f.Write(data)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// Resolve returns the streams of the session with the session defaults
+// applied and the streams given by flags on top. Flags for a source declared
+// in the file override its values, flags without a source override every
+// stream of the file.
+func (s *SessionConfig) Resolve(flags []StreamConfig) []StreamConfig {
+	var streams []StreamConfig
+	if len(flags) == 1 && flags[0].Source == "" && len(s.Streams) > 0 {
+		for _, stream := range s.Streams {
+			streams = append(streams, stream.override(flags[0]))
+		}
+	} else {
+		for _, f := range flags {
+			base := StreamConfig{}
+			for _, stream := range s.Streams {
+				if stream.Source == f.Source {
+					base = stream
+					break
+				}
+			}
+			streams = append(streams, base.override(f))
+		}
+	}
+
+	for i := range streams {
+		if streams[i].Project == "" {
+			streams[i].Project = s.Project
+		}
+
+		if streams[i].Since == "" {
+			streams[i].Since = s.Since
+		}
+	}
+
+	return streams
+}
+
+func (c StreamConfig) override(f StreamConfig) StreamConfig {
+	if f.Project != "" {
+		c.Project = f.Project
+	}
+
+	if f.Source != "" {
+		c.Source = f.Source
+	}
+
+	if f.ForwardTo != "" {
+		c.ForwardTo = f.ForwardTo
+	}
+
+	if f.Since != "" {
+		c.Since = f.Since
+	}
+
+	return c
+}
+
+// EventFilter returns the filter of the stream's events and filter, nil when it forwards every event.
+func (c *StreamConfig) EventFilter() (*EventFilter, error) {
+	if len(c.Events) == 0 && c.Filter == "" {
+		return nil, nil
+	}
+
+	return NewEventFilter(c.Events, c.Filter)
+}
+
+// StreamsFromFlags pairs repeated --project, --source-name and --forward-to
+// values by position. A flag given once applies to every stream, otherwise
+// every flag must be given the same number of times.
+func StreamsFromFlags(projects, sources, forwardTos []string) ([]StreamConfig, error) {
+	n := 1
+	for _, values := range [][]string{projects, sources, forwardTos} {
+		if len(values) > 1 {
+			if n > 1 && len(values) != n {
+				return nil, errors.New("--project, --source-name and --forward-to must be given once or the same number of times")
+			}
+			n = len(values)
+		}
+	}
+
+	at := func(values []string, i int) string {
+		switch len(values) {
+		case 0:
+			return ""
+		case 1:
+			return values[0]
+		default:
+			return values[i]
+		}
+	}
+
+	streams := make([]StreamConfig, n)
+	for i := range streams {
+		streams[i] = StreamConfig{
+			Project:   at(projects, i),
+			Source:    at(sources, i),
+			ForwardTo: at(forwardTos, i),
+		}
+	}
+
+	return streams, nil
+}
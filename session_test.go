@@ -0,0 +1,197 @@
+package convoy_cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamsFromFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		projects   []string
+		sources    []string
+		forwardTos []string
+		want       []StreamConfig
+		wantErr    bool
+	}{
+		{
+			name:       "single stream",
+			sources:    []string{"stripe"},
+			forwardTos: []string{"http://localhost:8080"},
+			want:       []StreamConfig{{Source: "stripe", ForwardTo: "http://localhost:8080"}},
+		},
+		{
+			name:       "paired by position",
+			projects:   []string{"payments", "ci"},
+			sources:    []string{"stripe", "github"},
+			forwardTos: []string{"http://localhost:8080", "http://localhost:8081"},
+			want: []StreamConfig{
+				{Project: "payments", Source: "stripe", ForwardTo: "http://localhost:8080"},
+				{Project: "ci", Source: "github", ForwardTo: "http://localhost:8081"},
+			},
+		},
+		{
+			name:       "single value applies to every stream",
+			projects:   []string{"payments", "ci"},
+			sources:    []string{"stripe"},
+			forwardTos: []string{"http://localhost:8080"},
+			want: []StreamConfig{
+				{Project: "payments", Source: "stripe", ForwardTo: "http://localhost:8080"},
+				{Project: "ci", Source: "stripe", ForwardTo: "http://localhost:8080"},
+			},
+		},
+		{
+			name:       "mismatched counts",
+			projects:   []string{"payments", "ci"},
+			sources:    []string{"stripe", "github", "shopify"},
+			forwardTos: []string{"http://localhost:8080"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streams, err := StreamsFromFlags(tt.projects, tt.sources, tt.forwardTos)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, streams)
+		})
+	}
+}
+
+func TestLoadSessionConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.yml")
+	data := `
+streams:
+  - project: payments
+    source: stripe
+    forward_to: http://localhost:8080/stripe
+  - source: github
+    forward_to: http://localhost:8081/github
+    since: 1h
+`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+	s, err := LoadSessionConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, []StreamConfig{
+		{Project: "payments", Source: "stripe", ForwardTo: "http://localhost:8080/stripe"},
+		{Source: "github", ForwardTo: "http://localhost:8081/github", Since: "1h"},
+	}, s.Streams)
+
+	require.NoError(t, os.WriteFile(path, []byte("streams: []\n"), 0600))
+	_, err = LoadSessionConfig(path)
+	require.Error(t, err)
+}
+
+func TestFindSessionConfig(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "a", "b")
+	require.NoError(t, os.MkdirAll(dir, 0700))
+
+	path, err := FindSessionConfig(dir)
+	require.NoError(t, err)
+	require.Empty(t, path)
+
+	want := filepath.Join(root, "a", SessionConfigName)
+	require.NoError(t, os.WriteFile(want, []byte("streams: []\n"), 0600))
+
+	path, err = FindSessionConfig(dir)
+	require.NoError(t, err)
+	require.Equal(t, want, path)
+}
+
+func TestFindSessionConfig_StopsAtRepoOrHome(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, SessionConfigName), []byte("streams: []\n"), 0600))
+
+	repo := filepath.Join(root, "repo")
+	dir := filepath.Join(repo, "cmd")
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".git"), []byte("gitdir: ../.git/worktrees/repo\n"), 0600))
+
+	path, err := FindSessionConfig(dir)
+	require.NoError(t, err)
+	require.Empty(t, path)
+
+	want := filepath.Join(repo, SessionConfigName)
+	require.NoError(t, os.WriteFile(want, []byte("streams: []\n"), 0600))
+
+	path, err = FindSessionConfig(dir)
+	require.NoError(t, err)
+	require.Equal(t, want, path)
+
+	home := filepath.Join(root, "home")
+	dir = filepath.Join(home, "projects")
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	t.Setenv("HOME", home)
+
+	path, err = FindSessionConfig(dir)
+	require.NoError(t, err)
+	require.Empty(t, path)
+}
+
+func TestSessionConfig_Resolve(t *testing.T) {
+	session := &SessionConfig{
+		Project: "payments",
+		Since:   "1h",
+		Streams: []StreamConfig{
+			{Source: "stripe", ForwardTo: "http://localhost:8080/stripe", Events: []string{"invoice.*"}},
+			{Project: "ci", Source: "github", ForwardTo: "http://localhost:8081/github"},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		flags []StreamConfig
+		want  []StreamConfig
+	}{
+		{
+			name:  "no flags",
+			flags: []StreamConfig{{}},
+			want: []StreamConfig{
+				{Project: "payments", Source: "stripe", ForwardTo: "http://localhost:8080/stripe", Since: "1h", Events: []string{"invoice.*"}},
+				{Project: "ci", Source: "github", ForwardTo: "http://localhost:8081/github", Since: "1h"},
+			},
+		},
+		{
+			name:  "flags without a source override every stream",
+			flags: []StreamConfig{{ForwardTo: "http://localhost:9000", Since: "5m"}},
+			want: []StreamConfig{
+				{Project: "payments", Source: "stripe", ForwardTo: "http://localhost:9000", Since: "5m", Events: []string{"invoice.*"}},
+				{Project: "ci", Source: "github", ForwardTo: "http://localhost:9000", Since: "5m"},
+			},
+		},
+		{
+			name:  "flags for a declared source",
+			flags: []StreamConfig{{Source: "stripe", ForwardTo: "http://localhost:9000"}},
+			want: []StreamConfig{
+				{Project: "payments", Source: "stripe", ForwardTo: "http://localhost:9000", Since: "1h", Events: []string{"invoice.*"}},
+			},
+		},
+		{
+			name:  "flags for another source",
+			flags: []StreamConfig{{Source: "shopify", ForwardTo: "http://localhost:9000"}},
+			want: []StreamConfig{
+				{Project: "payments", Source: "shopify", ForwardTo: "http://localhost:9000", Since: "1h"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, session.Resolve(tt.flags))
+		})
+	}
+
+	// without a session config the flags are the streams
+	flags := []StreamConfig{{Source: "stripe", ForwardTo: "http://localhost:8080"}}
+	require.Equal(t, flags, (&SessionConfig{}).Resolve(flags))
+}
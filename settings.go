@@ -0,0 +1,499 @@
+package convoy_cli
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/frain-dev/convoy-cli/util"
+	"gopkg.in/yaml.v3"
+)
+
+// ListenDefaults are used by listen for the values neither flags nor the
+// session config set.
+type ListenDefaults struct {
+	ForwardTo string `yaml:"forward_to,omitempty"`
+	Since     string `yaml:"since,omitempty"`
+}
+
+// Keys of the settings read and changed by Get, Set and Unset. Profile keys
+// apply to the profile in use, or to a named one as profiles.<name>.<key>.
+const (
+	KeyActiveProfile   = "active_profile"
+	KeySecretStore     = "secret_store"
+	KeyListenForwardTo = "listen.forward_to"
+	KeyListenSince     = "listen.since"
+
+	KeyHost            = "host"
+	KeyApiKey          = "api_key"
+	KeyActiveProjectID = "active_project_id"
+)
+
+// ConfigKeys lists the keys of Get, Set and Unset.
+var ConfigKeys = []string{
+	KeyActiveProfile, KeySecretStore, KeyListenForwardTo, KeyListenSince,
+	KeyHost, KeyApiKey, KeyActiveProjectID,
+}
+
+// maskedSecret replaces secrets in the output of View and Get.
+const maskedSecret = "********"
+
+// MaskSecret hides all of a secret but its last 4 characters.
+func MaskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return maskedSecret
+	}
+	return maskedSecret + secret[len(secret)-4:]
+}
+
+// profileKey splits a profile key into the profile it applies to and the
+// key, profile is empty for the profile in use.
+func profileKey(key string) (string, string, bool) {
+	if !strings.HasPrefix(key, "profiles.") {
+		return "", key, isProfileKey(key)
+	}
+
+	rest := strings.TrimPrefix(key, "profiles.")
+	i := strings.LastIndex(rest, ".")
+	if i <= 0 {
+		return "", key, false
+	}
+
+	return rest[:i], rest[i+1:], isProfileKey(rest[i+1:])
+}
+
+func isProfileKey(key string) bool {
+	return key == KeyHost || key == KeyApiKey || key == KeyActiveProjectID
+}
+
+// settingProfile returns the profile a key applies to.
+func (c *Config) settingProfile(name string) (*Profile, error) {
+	if util.IsStringEmpty(name) {
+		if _, ok := c.Profiles[c.profileName]; !ok {
+			return nil, errors.New("no profile is in use, run `convoy-cli login` or set profiles.<name>.host")
+		}
+		return c.Profile, nil
+	}
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found", name)
+	}
+
+	if name == c.profileName {
+		return c.Profile, nil
+	}
+
+	return p, c.loadApiKey(p)
+}
+
+// Get returns the value of a setting, api keys are masked unless reveal is set.
+func (c *Config) Get(key string, reveal bool) (string, error) {
+	switch key {
+	case KeyActiveProfile:
+		return c.ActiveProfile, nil
+	case KeySecretStore:
+		return c.SecretStore, nil
+	case KeyListenForwardTo, KeyListenSince:
+		if c.Listen == nil {
+			return "", nil
+		}
+		if key == KeyListenSince {
+			return c.Listen.Since, nil
+		}
+		return c.Listen.ForwardTo, nil
+	}
+
+	name, key, ok := profileKey(key)
+	if !ok {
+		return "", unknownKey(key)
+	}
+
+	p, err := c.settingProfile(name)
+	if err != nil {
+		return "", err
+	}
+
+	switch key {
+	case KeyHost:
+		return p.Host, nil
+	case KeyActiveProjectID:
+		return p.ActiveProjectID, nil
+	default:
+		if reveal || util.IsStringEmpty(p.ActiveApiKey) {
+			return p.ActiveApiKey, nil
+		}
+		return MaskSecret(p.ActiveApiKey), nil
+	}
+}
+
+// Set changes a setting after validating the value. Setting the host of a
+// profile that doesn't exist creates it.
+func (c *Config) Set(key, value string) error {
+	if util.IsStringEmpty(value) {
+		return fmt.Errorf("%s cannot be empty, use unset to clear it", key)
+	}
+
+	switch key {
+	case KeyActiveProfile:
+		if _, ok := c.Profiles[value]; !ok {
+			return fmt.Errorf("profile %q not found", value)
+		}
+		c.ActiveProfile = value
+		return nil
+	case KeySecretStore:
+		if value != SecretStoreKeyring && value != SecretStoreFile {
+			return fmt.Errorf("secret_store must be %s or %s", SecretStoreKeyring, SecretStoreFile)
+		}
+		c.SecretStore = value
+		return nil
+	case KeyListenForwardTo:
+		if err := validateURL(value); err != nil {
+			return err
+		}
+		c.listenDefaults().ForwardTo = value
+		return nil
+	case KeyListenSince:
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			if _, err = time.ParseDuration(value); err != nil {
+				return errors.New("listen.since must be a timestamp (e.g. 2013-01-02T13:23:37Z) or a duration (e.g. 42m)")
+			}
+		}
+		c.listenDefaults().Since = value
+		return nil
+	}
+
+	name, field, ok := profileKey(key)
+	if !ok {
+		return unknownKey(key)
+	}
+
+	if field == KeyHost && !util.IsStringEmpty(name) && c.Profiles[name] == nil {
+		c.Profiles[name] = &Profile{}
+	}
+
+	p, err := c.settingProfile(name)
+	if err != nil {
+		return err
+	}
+
+	switch field {
+	case KeyHost:
+		if err = validateURL(value); err != nil {
+			return err
+		}
+
+		if !util.IsStringEmpty(p.Host) && IsNewHost(p.Host, value) {
+			// the projects and devices belong to the previous host
+			p.ActiveProjectID = ""
+			p.Projects = nil
+		}
+		p.Host = value
+	case KeyActiveProjectID:
+		if len(p.Projects) > 0 && !hasProject(p.Projects, value) {
+			return fmt.Errorf("project %s not found\nRun `convoy-cli project refresh` to refresh the project list", value)
+		}
+		p.ActiveProjectID = value
+	default:
+		p.ActiveApiKey = value
+	}
+
+	return nil
+}
+
+// Unset clears a setting, unsetting an api key deletes it from the secret store.
+func (c *Config) Unset(key string) error {
+	switch key {
+	case KeyActiveProfile:
+		c.ActiveProfile = ""
+		return nil
+	case KeySecretStore:
+		c.SecretStore = ""
+		return nil
+	case KeyListenForwardTo, KeyListenSince:
+		if c.Listen == nil {
+			return nil
+		}
+		if key == KeyListenSince {
+			c.Listen.Since = ""
+		} else {
+			c.Listen.ForwardTo = ""
+		}
+		if *c.Listen == (ListenDefaults{}) {
+			c.Listen = nil
+		}
+		return nil
+	}
+
+	name, field, ok := profileKey(key)
+	if !ok {
+		return unknownKey(key)
+	}
+
+	p, err := c.settingProfile(name)
+	if err != nil {
+		return err
+	}
+
+	switch field {
+	case KeyHost:
+		p.Host = ""
+		p.ActiveProjectID = ""
+		p.Projects = nil
+	case KeyActiveProjectID:
+		p.ActiveProjectID = ""
+	default:
+		if util.IsStringEmpty(name) {
+			name = c.profileName
+		}
+		c.deleteApiKey(name, c.Profiles[name])
+
+		// p is a copy of the file's profile when values are overridden
+		for _, profile := range []*Profile{p, c.Profiles[name]} {
+			profile.ApiKeyRef = ""
+			profile.ActiveApiKey = ""
+			profile.storedApiKey = ""
+		}
+	}
+
+	return nil
+}
+
+// View returns the config file with the api keys that were loaded masked.
+func (c *Config) View() ([]byte, error) {
+	c.writeBackProfile()
+
+	type profileView struct {
+		Profile `yaml:",inline"`
+		ApiKey  string `yaml:"api_key,omitempty"`
+	}
+
+	view := struct {
+		Version       int                     `yaml:"version"`
+		ActiveProfile string                  `yaml:"active_profile"`
+		SecretStore   string                  `yaml:"secret_store,omitempty"`
+		Listen        *ListenDefaults         `yaml:"listen,omitempty"`
+		MachineID     string                  `yaml:"machine_id,omitempty"`
+		Profiles      map[string]*profileView `yaml:"profiles"`
+	}{
+		Version:       ConfigVersion,
+		ActiveProfile: c.ActiveProfile,
+		SecretStore:   c.SecretStore,
+		Listen:        c.Listen,
+		MachineID:     c.MachineID,
+		Profiles:      map[string]*profileView{},
+	}
+
+	for name, p := range c.Profiles {
+		v := &profileView{Profile: *p}
+		v.ActiveApiKey = ""
+		if !util.IsStringEmpty(p.ActiveApiKey) {
+			v.ApiKey = MaskSecret(p.ActiveApiKey)
+		}
+		view.Profiles[name] = v
+	}
+
+	return yaml.Marshal(&view)
+}
+
+// Marshal returns the config file as WriteToDisk writes it, for editing.
+func (c *Config) Marshal() ([]byte, error) {
+	c.writeBackProfile()
+	return c.marshal()
+}
+
+// sharedConfig is what Export writes and Import reads: the settings a team
+// can share, without api keys, projects or devices.
+type sharedConfig struct {
+	Version       int                       `yaml:"version"`
+	ActiveProfile string                    `yaml:"active_profile,omitempty"`
+	Listen        *ListenDefaults           `yaml:"listen,omitempty"`
+	Profiles      map[string]*sharedProfile `yaml:"profiles"`
+}
+
+type sharedProfile struct {
+	Host string `yaml:"host"`
+}
+
+// Export returns the settings that can be shared with other users.
+func (c *Config) Export() ([]byte, error) {
+	shared := sharedConfig{
+		Version:       ConfigVersion,
+		ActiveProfile: c.ActiveProfile,
+		Listen:        c.Listen,
+		Profiles:      map[string]*sharedProfile{},
+	}
+
+	for name, p := range c.Profiles {
+		shared.Profiles[name] = &sharedProfile{Host: p.Host}
+	}
+
+	return yaml.Marshal(&shared)
+}
+
+// Import adds the profiles and listen defaults of an exported config. A
+// profile that exists with another host is only replaced when overwrite
+// is set, as its login would be lost. It returns the imported profiles.
+func (c *Config) Import(data []byte, overwrite bool) ([]string, error) {
+	var shared sharedConfig
+	err := yaml.Unmarshal(data, &shared)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config export: %v", err)
+	}
+
+	if shared.Version > ConfigVersion {
+		return nil, fmt.Errorf("the export has version %d, this convoy-cli only supports up to version %d", shared.Version, ConfigVersion)
+	}
+
+	var imported []string
+	for _, name := range sortedKeys(shared.Profiles) {
+		sp := shared.Profiles[name]
+		if sp == nil || validateURL(sp.Host) != nil {
+			return nil, fmt.Errorf("profile %s has an invalid host", name)
+		}
+
+		p, ok := c.Profiles[name]
+		if ok && p.Host == sp.Host {
+			continue
+		}
+
+		if ok && !overwrite {
+			return nil, fmt.Errorf("profile %s already exists with host %s, import with overwrite to replace it", name, p.Host)
+		}
+
+		if ok {
+			c.deleteApiKey(name, p)
+		}
+		c.Profiles[name] = &Profile{Host: sp.Host}
+		imported = append(imported, name)
+	}
+
+	if shared.Listen != nil {
+		if !util.IsStringEmpty(shared.Listen.ForwardTo) {
+			if err = c.Set(KeyListenForwardTo, shared.Listen.ForwardTo); err != nil {
+				return nil, err
+			}
+		}
+
+		if !util.IsStringEmpty(shared.Listen.Since) {
+			if err = c.Set(KeyListenSince, shared.Listen.Since); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, ok := c.Profiles[c.ActiveProfile]; !ok {
+		if _, ok = c.Profiles[shared.ActiveProfile]; ok {
+			c.ActiveProfile = shared.ActiveProfile
+		}
+	}
+
+	return imported, nil
+}
+
+// Replace replaces the settings with a config file edited by the user. The
+// profiles kept keep their api key, the removed ones have it deleted.
+func (c *Config) Replace(data []byte) error {
+	edited := &Config{path: c.path}
+	m, err := edited.planMigration(data)
+	if err != nil {
+		return err
+	}
+
+	if m != nil {
+		data = m.migrated
+	}
+
+	err = yaml.Unmarshal(data, edited)
+	if err != nil {
+		return edited.corrupt(err)
+	}
+
+	for _, name := range sortedKeys(edited.Profiles) {
+		p := edited.Profiles[name]
+		if p == nil {
+			return fmt.Errorf("profile %s is empty", name)
+		}
+
+		if err = validateURL(p.Host); err != nil {
+			return fmt.Errorf("profile %s: %v", name, err)
+		}
+
+		if old, ok := c.Profiles[name]; ok && old.ApiKeyRef == p.ApiKeyRef {
+			// a key typed into the file replaces the stored one
+			p.storedApiKey = old.storedApiKey
+			if util.IsStringEmpty(p.ActiveApiKey) {
+				p.ActiveApiKey = old.ActiveApiKey
+			}
+		} else if !util.IsStringEmpty(p.ApiKeyRef) {
+			if _, _, err = parseSecretRef(p.ApiKeyRef); err != nil {
+				return fmt.Errorf("profile %s: %v", name, err)
+			}
+		}
+	}
+
+	if !util.IsStringEmpty(edited.ActiveProfile) && edited.Profiles[edited.ActiveProfile] == nil {
+		return fmt.Errorf("active profile %q not found", edited.ActiveProfile)
+	}
+
+	if edited.SecretStore != "" && edited.SecretStore != SecretStoreKeyring && edited.SecretStore != SecretStoreFile {
+		return fmt.Errorf("secret_store must be %s or %s", SecretStoreKeyring, SecretStoreFile)
+	}
+
+	for _, name := range c.ProfileNames() {
+		if _, ok := edited.Profiles[name]; !ok {
+			c.deleteApiKey(name, c.Profiles[name])
+		}
+	}
+
+	c.ActiveProfile = edited.ActiveProfile
+	c.SecretStore = edited.SecretStore
+	c.Listen = edited.Listen
+	c.Profiles = edited.Profiles
+
+	// a removed machine id would register this machine as new devices
+	if !util.IsStringEmpty(edited.MachineID) {
+		c.MachineID = edited.MachineID
+	}
+	if c.Profiles == nil {
+		c.Profiles = map[string]*Profile{}
+	}
+
+	c.Profile = &Profile{}
+	if p, ok := c.Profiles[c.profileName]; ok {
+		c.Profile = p
+	}
+
+	return nil
+}
+
+func (c *Config) listenDefaults() *ListenDefaults {
+	if c.Listen == nil {
+		c.Listen = &ListenDefaults{}
+	}
+	return c.Listen
+}
+
+func hasProject(projects []ConfigProject, id string) bool {
+	for _, p := range projects {
+		if p.UID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func validateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || util.IsStringEmpty(u.Scheme) || util.IsStringEmpty(u.Host) {
+		return fmt.Errorf("%q is not a valid url, e.g. https://cli.getconvoy.io", value)
+	}
+	return nil
+}
+
+func unknownKey(key string) error {
+	return fmt.Errorf("unknown config key %q, valid keys are %s and profiles.<name>.{%s,%s,%s}",
+		key, strings.Join(ConfigKeys, ", "), KeyHost, KeyApiKey, KeyActiveProjectID)
+}
@@ -0,0 +1,216 @@
+package convoy_cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const settingsConfig = `
+active_profile: cloud
+profiles:
+  cloud:
+    host: https://cli.getconvoy.io
+    active_api_key: cloud-secret-key
+    active_project_id: p1
+    projects:
+      - uid: p1
+        name: payments
+  dev.local:
+    host: http://localhost:5005
+    active_api_key: local-secret-key
+`
+
+func TestConfig_GetSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "host", key: "host", value: "https://convoy.example.com", want: "https://convoy.example.com"},
+		{name: "invalid host", key: "host", value: "convoy.example.com", wantErr: true},
+		{name: "named profile with a dot", key: "profiles.dev.local.host", value: "http://localhost:5006", want: "http://localhost:5006"},
+		{name: "new profile", key: "profiles.staging.host", value: "https://staging.example.com", want: "https://staging.example.com"},
+		{name: "missing profile", key: "profiles.staging.active_project_id", value: "p1", wantErr: true},
+		{name: "active project", key: "active_project_id", value: "p1", want: "p1"},
+		{name: "unknown project", key: "active_project_id", value: "p9", wantErr: true},
+		{name: "active profile", key: "active_profile", value: "dev.local", want: "dev.local"},
+		{name: "unknown active profile", key: "active_profile", value: "staging", wantErr: true},
+		{name: "secret store", key: "secret_store", value: "file", want: "file"},
+		{name: "invalid secret store", key: "secret_store", value: "vault", wantErr: true},
+		{name: "listen since duration", key: "listen.since", value: "42m", want: "42m"},
+		{name: "listen since timestamp", key: "listen.since", value: "2013-01-02T13:23:37Z", want: "2013-01-02T13:23:37Z"},
+		{name: "invalid listen since", key: "listen.since", value: "yesterday", wantErr: true},
+		{name: "listen forward to", key: "listen.forward_to", value: "http://localhost:8080", want: "http://localhost:8080"},
+		{name: "api key is masked", key: "api_key", value: "new-secret-key", want: "********-key"},
+		{name: "unknown key", key: "projects", value: "p1", wantErr: true},
+		{name: "empty value", key: "host", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, settingsConfig)
+			t.Setenv(PassphraseEnv, "passphrase")
+
+			c, err := LoadConfig(ConfigOverrides{})
+			require.NoError(t, err)
+
+			err = c.Set(tt.key, tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, c.WriteToDisk())
+
+			c, err = LoadConfig(ConfigOverrides{})
+			require.NoError(t, err)
+
+			got, err := c.Get(tt.key, false)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfig_SetHostDropsProjects(t *testing.T) {
+	writeConfigFile(t, settingsConfig)
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.NoError(t, c.Set("host", "https://convoy.example.com"))
+	require.Empty(t, c.ActiveProjectID)
+	require.Empty(t, c.Projects)
+}
+
+func TestConfig_Unset(t *testing.T) {
+	writeConfigFile(t, settingsConfig)
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.NoError(t, c.Set("listen.since", "1h"))
+	require.NoError(t, c.Unset("listen.since"))
+	require.Nil(t, c.Listen)
+
+	ref := c.Profiles["dev.local"].ApiKeyRef
+	require.NotEmpty(t, ref)
+	require.NoError(t, c.Unset("profiles.dev.local.api_key"))
+	require.NoError(t, c.WriteToDisk())
+
+	// the key is deleted from the secret store
+	name, id, err := parseSecretRef(ref)
+	require.NoError(t, err)
+	s, err := c.store(name)
+	require.NoError(t, err)
+	_, err = s.Get(id)
+	require.ErrorIs(t, err, ErrSecretNotFound)
+
+	c, err = LoadConfig(ConfigOverrides{Profile: "dev.local"})
+	require.NoError(t, err)
+	require.Empty(t, c.ActiveApiKey)
+	require.Empty(t, c.ApiKeyRef)
+}
+
+func TestConfig_View(t *testing.T) {
+	writeConfigFile(t, settingsConfig)
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+
+	view, err := c.View()
+	require.NoError(t, err)
+	require.Contains(t, string(view), "api_key: '********-key'")
+	require.NotContains(t, string(view), "cloud-secret-key")
+}
+
+func TestConfig_ExportImport(t *testing.T) {
+	writeConfigFile(t, settingsConfig)
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.NoError(t, c.Set("listen.forward_to", "http://localhost:8080"))
+
+	data, err := c.Export()
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "secret")
+	require.NotContains(t, string(data), "api_key")
+	require.NotContains(t, string(data), "payments")
+
+	tests := []struct {
+		name      string
+		config    string
+		overwrite bool
+		want      []string
+		wantHost  string
+		wantErr   bool
+	}{
+		{
+			name:     "new config",
+			config:   "version: 3\nactive_profile: \"\"\nprofiles: {}\n",
+			want:     []string{"cloud", "dev.local"},
+			wantHost: "https://cli.getconvoy.io",
+		},
+		{
+			name:     "same hosts",
+			config:   settingsConfig,
+			wantHost: "https://cli.getconvoy.io",
+		},
+		{
+			name:    "other host",
+			config:  "version: 3\nactive_profile: cloud\nprofiles:\n  cloud:\n    host: https://convoy.example.com\n",
+			wantErr: true,
+		},
+		{
+			name:      "overwrite other host",
+			config:    "version: 3\nactive_profile: cloud\nprofiles:\n  cloud:\n    host: https://convoy.example.com\n",
+			overwrite: true,
+			want:      []string{"cloud", "dev.local"},
+			wantHost:  "https://cli.getconvoy.io",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, tt.config)
+
+			c, err := LoadConfig(ConfigOverrides{})
+			require.NoError(t, err)
+
+			imported, err := c.Import(data, tt.overwrite)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, imported)
+			require.Equal(t, "cloud", c.ActiveProfile)
+			require.Equal(t, tt.wantHost, c.Profiles["cloud"].Host)
+			require.Equal(t, "http://localhost:8080", c.Listen.ForwardTo)
+		})
+	}
+}
+
+func TestConfig_Replace(t *testing.T) {
+	writeConfigFile(t, settingsConfig)
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+
+	data, err := c.Marshal()
+	require.NoError(t, err)
+
+	require.Error(t, c.Replace([]byte("profiles: [")))
+	require.Error(t, c.Replace([]byte("version: 3\nactive_profile: staging\nprofiles: {}\n")))
+	require.Error(t, c.Replace([]byte("version: 3\nprofiles:\n  cloud:\n    host: localhost\n")))
+
+	// the api keys are kept
+	require.NoError(t, c.Replace(append(data, []byte("listen:\n    since: 1h\n")...)))
+	require.NoError(t, c.WriteToDisk())
+
+	c, err = LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+	require.Equal(t, "cloud-secret-key", c.ActiveApiKey)
+	require.Equal(t, "1h", c.Listen.Since)
+}
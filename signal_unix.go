@@ -0,0 +1,24 @@
+//go:build !windows
+
+package convoy_cli
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyStats relays SIGUSR1, used to print the session summary on demand.
+func notifyStats(c chan os.Signal) {
+	signal.Notify(c, syscall.SIGUSR1)
+}
+
+// notifyShutdown relays the signals that end a listen session.
+func notifyShutdown(c chan os.Signal) {
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+}
+
+// isReloadSignal reports whether sig asks a session with a config file to reload it.
+func isReloadSignal(sig os.Signal) bool {
+	return sig == syscall.SIGHUP
+}
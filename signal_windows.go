@@ -0,0 +1,22 @@
+//go:build windows
+
+package convoy_cli
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyStats is a no-op, SIGUSR1 does not exist on windows.
+func notifyStats(c chan os.Signal) {}
+
+// notifyShutdown relays the signals that end a listen session.
+func notifyShutdown(c chan os.Signal) {
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+}
+
+// isReloadSignal is always false, SIGHUP does not exist on windows.
+func isReloadSignal(sig os.Signal) bool {
+	return false
+}
@@ -1,7 +1,11 @@
 package convoy_cli
 
 import (
+	"context"
 	"encoding/json"
+	"math/rand"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -11,11 +15,65 @@ type ListenRequest struct {
 	DeviceID   string `json:"device_id"`
 	SourceName string `json:"source_name"`
 
+	// ProjectName labels the project in logs and stats, ProjectID is used when empty
+	ProjectName string `json:"-"`
+
 	Since     string `json:"-"`
 	ForwardTo string `json:"-"`
+
+	// Mock is set when the listener answers events itself instead of forwarding them
+	Mock *MockConfig `json:"-"`
+
+	// Chaos is set when faults are injected between the listener and ForwardTo
+	Chaos *Chaos `json:"-"`
+
+	// Ledger is set when events already delivered to ForwardTo are suppressed,
+	// ForceDelivery delivers them again anyway
+	Ledger        *Ledger `json:"-"`
+	ForceDelivery bool    `json:"-"`
+
+	// Until is set when the session ends once enough events were delivered
+	Until *Until `json:"-"`
+
+	// StatsInterval is how often a line of session statistics is logged, zero disables it
+	StatsInterval time.Duration `json:"-"`
+
+	// ShowTimings prints an httpstat style breakdown of each forwarded request
+	ShowTimings bool `json:"-"`
+
+	// DrainTimeout is how long in-flight events may take to finish on shutdown
+	DrainTimeout time.Duration `json:"-"`
+
+	// Filter is set when only some events are forwarded, the rest are acked
+	Filter *EventFilter `json:"-"`
+
+	// Headers are added to every forwarded request, replacing the event's own
+	Headers map[string]string `json:"-"`
+
+	// Transform is set when the payload is rewritten before it is forwarded
+	Transform *Transform `json:"-"`
+
+	// EventTypeField is the path the event type is read from, e.g. data.type,
+	// for servers that stream events without their type
+	EventTypeField string `json:"-"`
 	// EventTypes []string `json:"event_types"`
 }
 
+func (l *ListenRequest) projectName() string {
+	if l.ProjectName != "" {
+		return l.ProjectName
+	}
+	return l.ProjectID
+}
+
+// streamName identifies the project and source of the request, e.g. in the session summary.
+func (l *ListenRequest) streamName() string {
+	if l.SourceName == "" {
+		return l.projectName()
+	}
+	return l.projectName() + "/" + l.SourceName
+}
+
 type LoginRequest struct {
 	HostName string `json:"host_name"`
 	DeviceID string `json:"device_id"`
@@ -65,7 +123,21 @@ type AckEventDelivery struct {
 }
 
 type CLIEvent struct {
-	UID     string              `json:"uid"`
-	Headers map[string][]string `json:"headers"`
-	Data    json.RawMessage     `json:"data"`
+	UID       string              `json:"uid"`
+	EventType string              `json:"event_type,omitempty"`
+	Headers   map[string][]string `json:"headers"`
+	Data      json.RawMessage     `json:"data"`
+
+	// ctx carries the event's trace span through the listener
+	ctx context.Context
+
+	// chaos draws the faults injected into this delivery of the event
+	chaos *rand.Rand
+}
+
+func (e *CLIEvent) context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
 }
@@ -0,0 +1,303 @@
+package convoy_cli
+
+import (
+	"container/list"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+const (
+	// Number of latencies kept per target to compute the percentiles from.
+	maxLatencySamples = 1024
+
+	// Number of recent event ids kept to recognise retried events.
+	maxSeenEvents = 10000
+)
+
+// Stats collects counters for a listen session. Its memory is bounded, so it
+// can run as long as the session: percentiles are computed from a sample of
+// the latencies and retries are recognised among the most recent events.
+type Stats struct {
+	mu      sync.Mutex
+	started time.Time
+
+	received     int
+	forwarded    int
+	acked        int
+	failed       int
+	retried      int
+	filtered     int
+	deduplicated int
+
+	bytesSent     int64
+	bytesReceived int64
+
+	statusCodes map[int]int
+	latencies   map[string]*latencySample
+	eventTypes  map[string]int
+	sources     map[string]int
+	seen        *recentSet
+	rand        *rand.Rand
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		started:     time.Now(),
+		statusCodes: map[int]int{},
+		latencies:   map[string]*latencySample{},
+		eventTypes:  map[string]int{},
+		sources:     map[string]int{},
+		seen:        newRecentSet(maxSeenEvents),
+		rand:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Received counts an event read from the websocket, an event already
+// received in this session is counted as retried.
+func (s *Stats) Received(event *CLIEvent, source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.received++
+	if s.seen.add(event.UID) {
+		s.retried++
+	}
+
+	eventType := event.EventType
+	if eventType == "" {
+		eventType = "unknown"
+	}
+	s.eventTypes[eventType]++
+
+	if source == "" {
+		source = "unknown"
+	}
+	s.sources[source]++
+}
+
+// Forwarded records a response from target, responses with a non 2xx
+// status code are counted as failed.
+func (s *Stats) Forwarded(target string, sent int, statusCode int, bodySize int, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.forwarded++
+	if !IsSuccessfulStatus(statusCode) {
+		s.failed++
+	}
+
+	s.bytesSent += int64(sent)
+	s.bytesReceived += int64(bodySize)
+	s.statusCodes[statusCode]++
+
+	l, ok := s.latencies[target]
+	if !ok {
+		l = &latencySample{}
+		s.latencies[target] = l
+	}
+	l.add(latency, s.rand)
+}
+
+// Failed counts an event that could not be delivered to the target at all.
+func (s *Stats) Failed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failed++
+}
+
+func (s *Stats) Acked() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.acked++
+}
+
+func (s *Stats) Filtered() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.filtered++
+}
+
+// Deduplicated counts a redelivered event the dedupe ledger suppressed.
+func (s *Stats) Deduplicated() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deduplicated++
+}
+
+// Line returns a one line summary of the counters.
+func (s *Stats) Line() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return fmt.Sprintf("received=%d forwarded=%d acked=%d failed=%d retried=%d filtered=%d deduplicated=%d sent=%s uptime=%v",
+		s.received, s.forwarded, s.acked, s.failed, s.retried, s.filtered, s.deduplicated,
+		formatBytes(s.bytesSent), time.Since(s.started).Round(time.Second))
+}
+
+// Summary returns the full session summary.
+func (s *Stats) Summary() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Session summary (%v)\n", time.Since(s.started).Round(time.Second))
+	fmt.Fprintf(b, "  received %d, forwarded %d, acked %d, failed %d, retried %d, filtered %d, deduplicated %d\n",
+		s.received, s.forwarded, s.acked, s.failed, s.retried, s.filtered, s.deduplicated)
+	fmt.Fprintf(b, "  bytes sent %s, received %s\n", formatBytes(s.bytesSent), formatBytes(s.bytesReceived))
+
+	if len(s.latencies) > 0 {
+		t := newSummaryTable(b, "Target", "Count", "p50", "p90", "p99", "Max")
+		for _, target := range sortedKeys(s.latencies) {
+			sample := s.latencies[target]
+			l := sortedDurations(sample.samples)
+			t.AppendRow(table.Row{target, sample.count, percentile(l, 50), percentile(l, 90), percentile(l, 99), sample.max})
+		}
+		t.Render()
+	}
+
+	if len(s.statusCodes) > 0 {
+		t := newSummaryTable(b, "Status", "Count")
+		codes := make([]int, 0, len(s.statusCodes))
+		for code := range s.statusCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			t.AppendRow(table.Row{code, s.statusCodes[code]})
+		}
+		t.Render()
+	}
+
+	if len(s.eventTypes) > 0 {
+		t := newSummaryTable(b, "Event Type", "Count")
+		for _, k := range sortedKeys(s.eventTypes) {
+			t.AppendRow(table.Row{k, s.eventTypes[k]})
+		}
+		t.Render()
+	}
+
+	if len(s.sources) > 0 {
+		t := newSummaryTable(b, "Source", "Count")
+		for _, k := range sortedKeys(s.sources) {
+			t.AppendRow(table.Row{k, s.sources[k]})
+		}
+		t.Render()
+	}
+
+	return b.String()
+}
+
+// latencySample keeps a uniform sample of the latencies of a target, with
+// their exact count and maximum.
+type latencySample struct {
+	count   int
+	max     time.Duration
+	samples []time.Duration
+}
+
+// add keeps d with the probability the sample needs to stay uniform
+// (reservoir sampling).
+func (l *latencySample) add(d time.Duration, r *rand.Rand) {
+	l.count++
+	if d > l.max {
+		l.max = d
+	}
+
+	if len(l.samples) < maxLatencySamples {
+		l.samples = append(l.samples, d)
+		return
+	}
+
+	if i := r.Intn(l.count); i < maxLatencySamples {
+		l.samples[i] = d
+	}
+}
+
+// recentSet remembers the most recently added keys, up to its size.
+type recentSet struct {
+	size  int
+	order *list.List
+	keys  map[string]*list.Element
+}
+
+func newRecentSet(size int) *recentSet {
+	return &recentSet{size: size, order: list.New(), keys: map[string]*list.Element{}}
+}
+
+// add adds key, forgetting the least recently added key when the set is
+// full. It reports whether key was already in the set.
+func (r *recentSet) add(key string) bool {
+	if e, ok := r.keys[key]; ok {
+		r.order.MoveToFront(e)
+		return true
+	}
+
+	r.keys[key] = r.order.PushFront(key)
+	if r.order.Len() > r.size {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.keys, oldest.Value.(string))
+	}
+	return false
+}
+
+func newSummaryTable(b *strings.Builder, header ...interface{}) table.Writer {
+	t := table.NewWriter()
+	t.SetOutputMirror(b)
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(header)
+	return t
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedDurations(d []time.Duration) []time.Duration {
+	l := make([]time.Duration, len(d))
+	copy(l, d)
+	sort.Slice(l, func(i, j int) bool { return l[i] < l[j] })
+	return l
+}
+
+// percentile returns the nearest-rank percentile p of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,57 @@
+package convoy_cli
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	s := NewStats()
+
+	s.Received(&CLIEvent{UID: "1", EventType: "invoice.paid"}, "stripe")
+	s.Received(&CLIEvent{UID: "1", EventType: "invoice.paid"}, "stripe")
+	s.Forwarded("http://localhost:8080", 10, 200, 2, time.Millisecond)
+	s.Forwarded("http://localhost:8080", 10, 500, 2, 3*time.Millisecond)
+	s.Acked()
+	s.Deduplicated()
+
+	require.Contains(t, s.Line(), "received=2 forwarded=2 acked=1 failed=1 retried=1 filtered=0 deduplicated=1 sent=20 B")
+	require.Contains(t, s.Summary(), "invoice.paid")
+}
+
+func TestStats_BoundedMemory(t *testing.T) {
+	s := NewStats()
+
+	for i := 1; i <= 3*maxLatencySamples; i++ {
+		s.Forwarded("http://localhost:8080", 1, 200, 1, time.Duration(i))
+	}
+
+	l := s.latencies["http://localhost:8080"]
+	require.Len(t, l.samples, maxLatencySamples)
+	require.Equal(t, 3*maxLatencySamples, l.count)
+	require.Equal(t, time.Duration(3*maxLatencySamples), l.max)
+
+	for i := 0; i <= maxSeenEvents; i++ {
+		s.Received(&CLIEvent{UID: fmt.Sprint(i)}, "stripe")
+	}
+	require.Len(t, s.seen.keys, maxSeenEvents)
+
+	// the oldest event was forgotten, a recent one is still a retry
+	s.Received(&CLIEvent{UID: fmt.Sprint(maxSeenEvents)}, "stripe")
+	s.Received(&CLIEvent{UID: "0"}, "stripe")
+	require.Equal(t, 1, s.retried)
+}
+
+func TestPercentile(t *testing.T) {
+	l := make([]time.Duration, 0, 100)
+	for i := 1; i <= 100; i++ {
+		l = append(l, time.Duration(i))
+	}
+
+	require.Equal(t, time.Duration(50), percentile(l, 50))
+	require.Equal(t, time.Duration(99), percentile(l, 99))
+	require.Equal(t, time.Duration(0), percentile(nil, 50))
+}
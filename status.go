@@ -0,0 +1,167 @@
+package convoy_cli
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/frain-dev/convoy-cli/util"
+)
+
+// LoginStatus describes the login of the profile in use, e.g. for `status`.
+type LoginStatus struct {
+	Profile  string `json:"profile"`
+	Host     string `json:"host,omitempty"`
+	LoggedIn bool   `json:"logged_in"`
+	// Verified is set when the host accepted the api key.
+	Verified bool `json:"verified"`
+	// Error is why the api key couldn't be verified.
+	Error    string `json:"error,omitempty"`
+	UserName string `json:"user_name,omitempty"`
+	Projects int    `json:"projects"`
+
+	Project  *ProjectStatus  `json:"project,omitempty"`
+	Listener *ListenerStatus `json:"listener,omitempty"`
+}
+
+// ProjectStatus describes the active project and this machine's device in it.
+type ProjectStatus struct {
+	UID          string     `json:"uid"`
+	Name         string     `json:"name"`
+	Type         string     `json:"type,omitempty"`
+	DeviceID     string     `json:"device_id,omitempty"`
+	DeviceStatus string     `json:"device_status,omitempty"`
+	LastSeenAt   *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// CheckLogin returns the login status of the profile in use. Unless offline
+// is set it verifies the api key with the host, a rejected key isn't logged
+// in, a host that can't be reached leaves the status of the config as it is.
+func (c *Config) CheckLogin(offline bool) *LoginStatus {
+	s := &LoginStatus{
+		Profile:  c.profileName,
+		Host:     c.Host,
+		LoggedIn: !util.IsStringEmpty(c.Host) && !util.IsStringEmpty(c.ActiveApiKey),
+		UserName: c.UserName,
+		Projects: len(c.Projects),
+	}
+
+	if p := c.FindProject(c.ActiveProjectID); p != nil {
+		s.Project = &ProjectStatus{UID: p.UID, Name: p.Name, Type: p.Type, DeviceID: p.DeviceID}
+	}
+
+	if !s.LoggedIn || offline {
+		return s
+	}
+
+	k, err := c.verify()
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+			s.LoggedIn = false
+		}
+		s.Error = err.Error()
+		return s
+	}
+
+	s.Verified = true
+	if k.projects >= 0 {
+		s.Projects = k.projects
+	}
+	if !util.IsStringEmpty(k.userName) {
+		s.UserName = k.userName
+	}
+
+	if s.Project == nil || util.IsStringEmpty(s.Project.DeviceID) {
+		return s
+	}
+
+	d, ok := k.devices[s.Project.DeviceID]
+	if !ok {
+		// the host removed the device, or the api key lost the project
+		s.Project.DeviceStatus = "removed"
+		return s
+	}
+
+	s.Project.DeviceStatus = d.Status
+	if d.LastSeenAt > 0 {
+		lastSeen := d.LastSeenAt.Time()
+		s.Project.LastSeenAt = &lastSeen
+	}
+	return s
+}
+
+// keyCheck is what the host answered when the api key was verified.
+type keyCheck struct {
+	// devices are the devices the api key sees, by id
+	devices map[string]Device
+	// projects is how many projects the api key has access to, -1 when
+	// the host didn't say
+	projects int
+	userName string
+	header   http.Header
+}
+
+// verify checks the api key with the host without writing the config or
+// registering a device. Hosts before CLIAPIVersion can't list devices, they
+// are sent a login with a device of this machine instead, which the host
+// looks up rather than creating one. The headers of the response are set
+// even when the key is rejected.
+func (c *Config) verify() (*keyCheck, error) {
+	client, err := NewClient(c.Host, c.ActiveApiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, header, err := client.listDevices()
+	if !errors.Is(err, ErrUnsupported) {
+		k := &keyCheck{devices: map[string]Device{}, projects: -1, header: header}
+		for _, d := range devices {
+			k.devices[d.UID] = d
+		}
+		return k, err
+	}
+
+	// without a device the host would register one for the login
+	deviceID := c.loginDeviceID()
+	if util.IsStringEmpty(deviceID) {
+		return &keyCheck{header: header}, errors.New("no project has a device of this machine, run `convoy-cli project refresh`")
+	}
+
+	hostName, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	response, header, err := client.Login(&LoginRequest{HostName: hostName, DeviceID: deviceID})
+	k := &keyCheck{devices: map[string]Device{}, header: header}
+	if err != nil {
+		return k, err
+	}
+
+	k.projects, k.userName = len(response.Projects), response.UserName
+	for _, rp := range response.Projects {
+		if rp.Device != nil && rp.Project != nil {
+			d := *rp.Device
+			d.ProjectID = rp.Project.UID
+			k.devices[d.UID] = d
+		}
+	}
+	return k, nil
+}
+
+// loginDeviceID returns the device of the active project, or of any project
+// when it has none, so a login finds an existing device.
+func (c *Config) loginDeviceID() string {
+	if p := c.FindProject(c.ActiveProjectID); p != nil && !util.IsStringEmpty(p.DeviceID) {
+		return p.DeviceID
+	}
+
+	for _, p := range c.Projects {
+		if !util.IsStringEmpty(p.DeviceID) {
+			return p.DeviceID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package convoy_cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_CheckLogin(t *testing.T) {
+	server := newFakeConvoyServer(t, "v23.05.1", time.Now(), false)
+
+	tests := []struct {
+		name         string
+		host         string
+		apiKey       string
+		offline      bool
+		wantLoggedIn bool
+		wantVerified bool
+		wantUser     string
+		wantErr      bool
+	}{
+		{name: "verified", host: server.URL, apiKey: "key", wantLoggedIn: true, wantVerified: true, wantUser: "Ada"},
+		{name: "revoked api key", host: server.URL, apiKey: "revoked", wantUser: "Grace", wantErr: true},
+		{name: "offline", host: server.URL, apiKey: "revoked", offline: true, wantLoggedIn: true, wantUser: "Grace"},
+		{name: "unreachable host", host: "http://127.0.0.1:1", apiKey: "key", wantLoggedIn: true, wantUser: "Grace", wantErr: true},
+		{name: "not logged in", host: server.URL, wantUser: "Grace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, fmt.Sprintf(`
+active_profile: default
+profiles:
+  default:
+    host: %s
+    active_api_key: %s
+    active_project_id: p1
+    user_name: Grace
+    projects:
+      - uid: p1
+        name: payments
+        type: incoming
+        device_id: d1
+`, tt.host, tt.apiKey))
+
+			c, err := LoadConfig(ConfigOverrides{})
+			require.NoError(t, err)
+
+			s := c.CheckLogin(tt.offline)
+			require.Equal(t, "default", s.Profile)
+			require.Equal(t, tt.wantLoggedIn, s.LoggedIn)
+			require.Equal(t, tt.wantVerified, s.Verified)
+			require.Equal(t, tt.wantUser, s.UserName)
+			require.Equal(t, tt.wantErr, s.Error != "")
+			require.NotNil(t, s.Project)
+			require.Equal(t, "d1", s.Project.DeviceID)
+		})
+	}
+}
+
+func TestConfig_CheckLoginReadOnly(t *testing.T) {
+	tests := []struct {
+		name         string
+		listsDevices bool
+		deviceID     string
+		want         []string
+		wantStatus   string
+		wantErr      string
+	}{
+		{name: "devices", listsDevices: true, deviceID: "d1", want: []string{"GET /stream/devices"}, wantStatus: "offline"},
+		{name: "host without devices", deviceID: "d1", want: []string{"GET /stream/devices", "POST /stream/login d1"}, wantStatus: "online"},
+		{name: "host without devices, no device", want: []string{"GET /stream/devices"}, wantErr: "no project has a device"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method + " " + r.URL.Path {
+				case "GET /stream/devices":
+					requests = append(requests, "GET /stream/devices")
+					if !tt.listsDevices {
+						http.NotFound(w, r)
+						return
+					}
+					fmt.Fprint(w, `{"devices": [{"uid": "d1", "project_id": "p1", "status": "offline"}]}`)
+				case "POST /stream/login":
+					var req LoginRequest
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+					requests = append(requests, "POST /stream/login "+req.DeviceID)
+					fmt.Fprint(w, `{"projects": [{"project": {"uid": "p1"}, "device": {"uid": "d1", "status": "online"}}]}`)
+				}
+			}))
+			defer server.Close()
+
+			path := writeConfigFile(t, fmt.Sprintf(`
+active_profile: default
+profiles:
+  default:
+    host: %s
+    active_api_key: key
+    active_project_id: p1
+    projects:
+      - uid: p1
+        name: payments
+        device_id: %s
+`, server.URL, tt.deviceID))
+
+			c, err := LoadConfig(ConfigOverrides{})
+			require.NoError(t, err)
+
+			// loading migrates the config, the check mustn't write it again
+			before, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			s := c.CheckLogin(false)
+			if tt.wantErr != "" {
+				require.Contains(t, s.Error, tt.wantErr)
+			} else {
+				require.True(t, s.Verified, s.Error)
+			}
+			require.Equal(t, tt.wantStatus, s.Project.DeviceStatus)
+			require.Equal(t, tt.want, requests)
+
+			after, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, string(before), string(after))
+		})
+	}
+}
+
+func TestConfig_SetProjectsKeepsUserName(t *testing.T) {
+	writeConfigFile(t, settingsConfig)
+
+	c, err := LoadConfig(ConfigOverrides{})
+	require.NoError(t, err)
+
+	c.SetProjects(&LoginResponse{UserName: "Ada"})
+	require.Equal(t, "Ada", c.UserName)
+
+	c.SetProjects(&LoginResponse{})
+	require.Equal(t, "Ada", c.UserName)
+}
@@ -0,0 +1,66 @@
+package convoy_cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+const (
+	TraceExporterOTLP = "otlp"
+	TraceExporterFile = "file"
+
+	tracerName = "github.com/frain-dev/convoy-cli"
+)
+
+// SetupTracing installs a global tracer provider exporting spans either over
+// OTLP/HTTP to endpoint, or as json to the file at path, and sets the W3C
+// trace context propagator so forwarded requests carry a traceparent header.
+// The returned function flushes and stops the exporter.
+func SetupTracing(exporter, endpoint, path string) (func(context.Context) error, error) {
+	var exp sdktrace.SpanExporter
+	var err error
+
+	switch exporter {
+	case TraceExporterOTLP:
+		var opts []otlptracehttp.Option
+		if endpoint != "" {
+			// otherwise the exporter uses OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4318
+			opts = append(opts, otlptracehttp.WithEndpoint(endpoint), otlptracehttp.WithInsecure())
+		}
+		exp, err = otlptracehttp.New(context.Background(), opts...)
+	case TraceExporterFile:
+		var f *os.File
+		f, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, use %s or %s", exporter, TraceExporterOTLP, TraceExporterFile)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceNameKey.String("convoy-cli"),
+		semconv.ServiceVersionKey.String(GetVersion()),
+	)
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,59 @@
+package convoy_cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestListener_EventSpans(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	// the forward span is started by the dispatcher with the global tracer
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(previous)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+
+	host := newFakeStreamServer(t, func(conn *websocket.Conn) {
+		err := conn.WriteMessage(websocket.TextMessage, []byte(`{"uid": "e1", "event_type": "invoice.paid", "data": {"amount": 100}}`))
+		require.NoError(t, err)
+		readUntilClosed(conn)
+	})
+
+	l := newTestListener()
+	l.tracer = tp.Tracer(tracerName)
+
+	err := waitListen(t, listenAsync(l, &ListenRequest{
+		ProjectID: "p1",
+		ForwardTo: target.URL,
+		Transform: &Transform{Set: map[string]interface{}{"env": "local"}},
+		Until:     &Until{Count: 1},
+	}, host))
+	require.NoError(t, err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range sr.Ended() {
+		spans[span.Name()] = span
+	}
+
+	event, ok := spans["convoy.event"]
+	require.True(t, ok, "no event span")
+
+	for _, name := range []string{"websocket.receive", "transform", "HTTP POST", "ack"} {
+		span, ok := spans[name]
+		require.True(t, ok, "no %s span", name)
+		require.Equal(t, event.SpanContext().TraceID(), span.SpanContext().TraceID(), name)
+		require.Equal(t, event.SpanContext().SpanID(), span.Parent().SpanID(), name)
+		require.False(t, span.StartTime().Before(event.StartTime()), name)
+	}
+}
@@ -0,0 +1,95 @@
+package convoy_cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Transform rewrites the json payload of an event before it is forwarded.
+// Paths are dot separated keys into the payload, e.g. customer.email.
+// Fields are renamed first, then removed, then set.
+type Transform struct {
+	Rename map[string]string      `yaml:"rename,omitempty"`
+	Remove []string               `yaml:"remove,omitempty"`
+	Set    map[string]interface{} `yaml:"set,omitempty"`
+}
+
+func (t *Transform) Apply(data []byte) ([]byte, error) {
+	// numbers are kept as they were sent, as float64 they'd lose the
+	// precision of ids above 2^53
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc map[string]interface{}
+	err := dec.Decode(&doc)
+	if err == nil {
+		if _, err = dec.Token(); err == io.EOF {
+			err = nil
+		} else if err == nil {
+			err = errors.New("trailing data")
+		}
+	}
+	if err != nil || doc == nil {
+		return nil, errors.New("transform: payload is not a json object")
+	}
+
+	for _, from := range sortedKeys(t.Rename) {
+		if v, ok := removePath(doc, from); ok {
+			if err = setPath(doc, t.Rename[from], v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, p := range t.Remove {
+		removePath(doc, p)
+	}
+
+	for _, p := range sortedKeys(t.Set) {
+		if err = setPath(doc, p, t.Set[p]); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+func removePath(doc map[string]interface{}, path string) (interface{}, bool) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := doc[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		doc = next
+	}
+
+	last := keys[len(keys)-1]
+	v, ok := doc[last]
+	delete(doc, last)
+	return v, ok
+}
+
+// setPath sets the value at path, creating the objects leading to it.
+func setPath(doc map[string]interface{}, path string, v interface{}) error {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		switch next := doc[key].(type) {
+		case map[string]interface{}:
+			doc = next
+		case nil:
+			m := map[string]interface{}{}
+			doc[key] = m
+			doc = m
+		default:
+			return fmt.Errorf("transform: %s is not an object", key)
+		}
+	}
+
+	doc[keys[len(keys)-1]] = v
+	return nil
+}
@@ -0,0 +1,76 @@
+package convoy_cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransform_Apply(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform Transform
+		data      string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "rename",
+			transform: Transform{Rename: map[string]string{"customer.email": "email"}},
+			data:      `{"customer":{"email":"a@b.c","id":1}}`,
+			want:      `{"customer":{"id":1},"email":"a@b.c"}`,
+		},
+		{
+			name:      "remove",
+			transform: Transform{Remove: []string{"customer.email", "missing.path"}},
+			data:      `{"customer":{"email":"a@b.c","id":1}}`,
+			want:      `{"customer":{"id":1}}`,
+		},
+		{
+			name:      "set creates objects",
+			transform: Transform{Set: map[string]interface{}{"meta.env": "local", "amount": 100}},
+			data:      `{"amount":1}`,
+			want:      `{"amount":100,"meta":{"env":"local"}}`,
+		},
+		{
+			name:      "set through a non object",
+			transform: Transform{Set: map[string]interface{}{"amount.value": 1}},
+			data:      `{"amount":1}`,
+			wantErr:   true,
+		},
+		{
+			name:      "payload is not an object",
+			transform: Transform{Remove: []string{"a"}},
+			data:      `[1,2]`,
+			wantErr:   true,
+		},
+		{
+			name:      "trailing data",
+			transform: Transform{Remove: []string{"a"}},
+			data:      `{"a":1} {"b":2}`,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.transform.Apply([]byte(tt.data))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestTransform_ApplyKeepsNumbers(t *testing.T) {
+	transform := Transform{Rename: map[string]string{"id": "order_id"}}
+
+	// compared as text, JSONEq would parse both sides into float64
+	got, err := transform.Apply([]byte(`{"amount":10.50,"id":12345678901234567891,"total":1e3}`))
+	require.NoError(t, err)
+	require.Equal(t, `{"amount":10.50,"order_id":12345678901234567891,"total":1e3}`, string(got))
+}
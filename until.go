@@ -0,0 +1,116 @@
+package convoy_cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/frain-dev/convoy-cli/util"
+)
+
+// Until ends a listen session once enough events were delivered, for use in
+// end-to-end tests. When Match is set only matching events count, otherwise
+// every event does. An Until without a Count only bounds the session to Timeout.
+type Until struct {
+	Count   int
+	Match   *Expression
+	Timeout time.Duration
+
+	// Output is a file the matched events are written to as a json array
+	Output string
+
+	mu      sync.Mutex
+	matched []*CLIEvent
+}
+
+func (u *Until) Validate() error {
+	if u.Count < 0 {
+		return fmt.Errorf("until count cannot be negative")
+	}
+
+	if u.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+
+	if u.Count == 0 && u.Timeout == 0 {
+		return fmt.Errorf("until needs a count or a timeout")
+	}
+
+	return nil
+}
+
+// Observe records the outcome of delivering the event. It reports whether the
+// condition is now met, or an error when a counted event failed to deliver.
+func (u *Until) Observe(event *CLIEvent, delivered bool) (bool, error) {
+	if u.Count == 0 {
+		return false, nil
+	}
+
+	if u.Match != nil && !u.Match.Match(event) {
+		return false, nil
+	}
+
+	if !delivered {
+		return false, fmt.Errorf("forwarding event %s failed", event.UID)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.matched = append(u.matched, event)
+	return len(u.matched) >= u.Count, nil
+}
+
+// Met reports whether enough events were delivered.
+func (u *Until) Met() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return len(u.matched) >= u.Count
+}
+
+// TimeoutError describes how far the session got before timing out, it is
+// nil for a session only bounded by the timeout.
+func (u *Until) TimeoutError() error {
+	if u.Count == 0 {
+		return nil
+	}
+	return u.UnmetError(fmt.Sprintf("timed out after %v", u.Timeout))
+}
+
+// UnmetError describes how far the session got before it ended for reason.
+func (u *Until) UnmetError(reason string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	what := "events"
+	if u.Match != nil {
+		what = fmt.Sprintf("events matching %s", u.Match)
+	}
+
+	return fmt.Errorf("%s waiting for %d %s, got %d", reason, u.Count, what, len(u.matched))
+}
+
+// WriteOutput writes the matched events to the output file, if one is set.
+func (u *Until) WriteOutput() error {
+	if util.IsStringEmpty(u.Output) {
+		return nil
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	events := u.matched
+	if events == nil {
+		events = []*CLIEvent{}
+	}
+
+	data, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(u.Output, data, 0644)
+}